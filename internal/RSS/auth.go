@@ -0,0 +1,141 @@
+package rss
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+/* Auth is what a private feed needs to let us in: Basic auth, a bearer token, private-token style
+query params (GitLab, Patreon), or any other headers. Everything is optional and they can be combined.
+It's stored encrypted (see internal/secret), never print it. */
+
+type Auth struct {
+	Username string            `json:"username,omitempty"`
+	Password string            `json:"password,omitempty"`
+	Token    string            `json:"token,omitempty"`
+	Query    map[string]string `json:"query,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+}
+
+func (a *Auth) apply(req *http.Request) {
+	if a == nil {
+		return
+	}
+
+	for name, value := range a.Headers {
+		req.Header.Set(name, value)
+	}
+
+	if a.Username != "" || a.Password != "" {
+		req.SetBasicAuth(a.Username, a.Password)
+	} else if a.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+a.Token)
+	}
+
+	if len(a.Query) > 0 {
+		query := req.URL.Query()
+		for name, value := range a.Query {
+			query.Set(name, value)
+		}
+		req.URL.RawQuery = query.Encode()
+	}
+}
+
+/* checkRedirect follows redirects like the default client does, but drops the custom headers once the redirect
+leaves the host they were for. Go's client only drops Authorization and Cookie by itself, so a PRIVATE-TOKEN
+header would otherwise reach wherever the feed sends us. */
+
+func (a *Auth) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	if req.URL.Host != via[0].URL.Host {
+		for name := range a.Headers {
+			req.Header.Del(name)
+		}
+	}
+	return nil
+}
+
+// SameSite tells whether link is on the same scheme and host as feedURL, the only place a feed's credentials may go
+
+func SameSite(feedURL, link string) bool {
+	a, err := url.Parse(feedURL)
+	if err != nil {
+		return false
+	}
+	b, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(a.Scheme, b.Scheme) && strings.EqualFold(a.Host, b.Host)
+}
+
+// redact takes the credential query params back out of a URL we applied them to, so it can be shown
+
+func (a *Auth) redact(raw string) string {
+	if a == nil || len(a.Query) == 0 {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	query := u.Query()
+	for name := range a.Query {
+		if query.Has(name) {
+			query.Set(name, "xxxxx")
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// Describe lists what kinds of credentials are set, without any of the values, for output like "basic, header X-Api-Key"
+
+func (a *Auth) Describe() string {
+	if a == nil {
+		return ""
+	}
+	var kinds []string
+	if a.Username != "" || a.Password != "" {
+		kinds = append(kinds, "basic")
+	} else if a.Token != "" {
+		kinds = append(kinds, "bearer")
+	}
+	for name := range a.Query {
+		kinds = append(kinds, "query "+name)
+	}
+	for name := range a.Headers {
+		kinds = append(kinds, "header "+name)
+	}
+	return strings.Join(kinds, ", ")
+}
+
+// RedactURL hides a password in the URL and the values of query params that look like secrets, for printing
+
+func RedactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+
+	query := u.Query()
+	changed := false
+	for name := range query {
+		lower := strings.ToLower(name)
+		for _, hint := range []string{"token", "key", "secret", "password", "auth", "sig"} {
+			if strings.Contains(lower, hint) {
+				query.Set(name, "xxxxx")
+				changed = true
+				break
+			}
+		}
+	}
+	if changed {
+		u.RawQuery = query.Encode()
+	}
+	return u.Redacted()
+}
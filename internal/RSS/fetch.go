@@ -0,0 +1,210 @@
+package rss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+/* These limits apply to everything gator downloads, feeds and full articles alike, so one slow or
+huge site can't hang the aggregator or eat all the memory. */
+
+const (
+	FetchTimeout = 30 * time.Second
+	MaxBodySize  = 10 << 20 // 10 MB
+)
+
+var httpClient = &http.Client{Timeout: FetchTimeout}
+
+// StatusError is a fetch the server answered with something other than 2xx, errors.As tells a 404 from a 500
+type StatusError struct {
+	URL        string
+	Status     string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status fetching %s: %s", e.URL, e.Status)
+}
+
+// FetchOptions are the per-feed extras for a fetch, the zero value is a plain GET
+type FetchOptions struct {
+	Auth *Auth
+	// Proxy overrides the global proxy for this feed, DirectProxy to go without one
+	Proxy string
+	// ServerName pins the name the TLS certificate is checked against, for feeds reached by IP or an internal alias
+	ServerName string
+	// AllowFile lets file:// URLs through. Only for feeds the user added, never for links found inside feeds.
+	AllowFile bool
+	// MaxSize caps the body in bytes, MaxBodySize when it's 0
+	MaxSize int64
+}
+
+func (opts FetchOptions) maxSize() int64 {
+	if opts.MaxSize > 0 {
+		return opts.MaxSize
+	}
+	return MaxBodySize
+}
+
+// FetchPage downloads any URL (like the article a post links to) with the same client, user agent and limits as FetchFeed
+
+func FetchPage(ctx context.Context, pageURL string) ([]byte, error) {
+	return FetchPageWith(ctx, pageURL, FetchOptions{})
+}
+
+func FetchPageWith(ctx context.Context, pageURL string, opts FetchOptions) ([]byte, error) {
+	res, err := Fetch(ctx, pageURL, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, &StatusError{URL: pageURL, Status: res.Status, StatusCode: res.StatusCode}
+	}
+
+	return res.Body, nil
+}
+
+// Response is everything a fetch saw, not just the body, so gator inspect can explain a feed that "doesn't work"
+type Response struct {
+	// URL is where we ended up after redirects
+	URL        string
+	Status     string
+	StatusCode int
+	Header     http.Header
+	Redirects  []Redirect
+	Body       []byte
+}
+
+// Redirect is one hop on the way, From answered with Status and sent us on
+type Redirect struct {
+	From   string `json:"from"`
+	Status string `json:"status"`
+}
+
+/* Fetch is the one place gator makes GET requests, everything else (FetchFeed, FetchPage, Inspect)
+goes through it. Unlike FetchPageWith it doesn't treat a 404 or 500 as an error, the caller gets the
+Response and decides. Who actually answers depends on the fetcher, see SetFetcher. */
+
+func Fetch(ctx context.Context, pageURL string, opts FetchOptions) (*Response, error) {
+	return currentFetcher().Fetch(ctx, pageURL, opts)
+}
+
+// HTTPFetcher is the real network: user agent, credentials, proxy, timeouts and the size limit all happen here
+type HTTPFetcher struct{}
+
+func (HTTPFetcher) Fetch(ctx context.Context, pageURL string, opts FetchOptions) (*Response, error) {
+	if strings.HasPrefix(pageURL, "file://") {
+		if !opts.AllowFile {
+			return nil, fmt.Errorf("file urls are only allowed for feeds: %s", pageURL)
+		}
+		return fetchFile(pageURL, opts.maxSize())
+	}
+
+	res, err := get(ctx, pageURL, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	// read one byte past the limit, so we can tell "exactly at the limit" from "too big"
+	limit := opts.maxSize()
+	data, err := io.ReadAll(io.LimitReader(res.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading the data from the body: %w", err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("response from %s is larger than %d bytes", pageURL, limit)
+	}
+
+	// every request after a redirect remembers the response that caused it, walk that chain backwards
+	var redirects []Redirect
+	for req := res.Request; req.Response != nil; req = req.Response.Request {
+		hop := Redirect{From: opts.Auth.redact(req.Response.Request.URL.String()), Status: req.Response.Status}
+		redirects = append([]Redirect{hop}, redirects...)
+	}
+
+	return &Response{
+		URL:        opts.Auth.redact(res.Request.URL.String()),
+		Status:     res.Status,
+		StatusCode: res.StatusCode,
+		Header:     res.Header,
+		Redirects:  redirects,
+		Body:       data,
+	}, nil
+}
+
+// get sends the GET request, the body is left to the caller
+
+func get(ctx context.Context, pageURL string, opts FetchOptions) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error in the request: %w", err)
+	}
+
+	request.Header.Set("User-Agent", "gator")
+	opts.Auth.apply(request)
+
+	client, err := clientFor(opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Auth != nil && len(opts.Auth.Headers) > 0 {
+		// the clients are shared, this request gets its own copy with the redirect check
+		withAuth := *client
+		withAuth.CheckRedirect = opts.Auth.checkRedirect
+		client = &withAuth
+	}
+
+	res, err := client.Do(request)
+	if err != nil {
+		// the error would repeat the request URL, private token query params included
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = pageURL
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
+// fetchFile reads a local feed file (file:///path/feed.xml) and dresses it up as a 200 response
+
+func fetchFile(fileURL string, limit int64) (*Response, error) {
+	u, err := url.Parse(fileURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file url: %w", err)
+	}
+
+	file, err := os.Open(u.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", u.Path, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%s is larger than %d bytes", u.Path, limit)
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", http.DetectContentType(data))
+
+	return &Response{
+		URL:        fileURL,
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Header:     header,
+		Body:       data,
+	}, nil
+}
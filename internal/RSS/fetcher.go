@@ -0,0 +1,156 @@
+package rss
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Fetcher answers Fetch calls, normally over HTTP, but it can also record or replay
+type Fetcher interface {
+	Fetch(ctx context.Context, pageURL string, opts FetchOptions) (*Response, error)
+}
+
+// The fetch modes the config file and the --record/--replay flags can pick
+const (
+	ModeLive   = ""
+	ModeRecord = "record"
+	ModeReplay = "replay"
+)
+
+var fetcher = struct {
+	sync.RWMutex
+	current Fetcher
+}{current: HTTPFetcher{}}
+
+func currentFetcher() Fetcher {
+	fetcher.RLock()
+	defer fetcher.RUnlock()
+	return fetcher.current
+}
+
+// SetFetcher swaps who answers every fetch from now on
+
+func SetFetcher(f Fetcher) {
+	fetcher.Lock()
+	defer fetcher.Unlock()
+	fetcher.current = f
+}
+
+// ConfigureFetcher picks the fetcher for a mode: live (""), "record" into dir, or "replay" from dir
+
+func ConfigureFetcher(mode, dir string) error {
+	switch mode {
+	case ModeLive:
+		SetFetcher(HTTPFetcher{})
+	case ModeRecord:
+		if dir == "" {
+			return errors.New("record mode needs a recordings directory")
+		}
+		SetFetcher(&Recorder{Dir: dir, Next: HTTPFetcher{}})
+	case ModeReplay:
+		if dir == "" {
+			return errors.New("replay mode needs a recordings directory")
+		}
+		SetFetcher(&Replayer{Dir: dir})
+	default:
+		return fmt.Errorf("unknown fetch mode %q, use record or replay", mode)
+	}
+	return nil
+}
+
+// ErrNoRecording is what replay returns for a URL nobody recorded
+var ErrNoRecording = errors.New("no recording for this url")
+
+/* A recording is one response saved as JSON, named after the sha256 of the URL it answered, so any URL
+(query strings, odd characters) maps to a plain file name. The URL is kept inside for humans. */
+
+type recording struct {
+	URL        string      `json:"url"`
+	FinalURL   string      `json:"final_url"`
+	Status     string      `json:"status"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Redirects  []Redirect  `json:"redirects,omitempty"`
+	Body       []byte      `json:"body"`
+}
+
+func recordingPath(dir, pageURL string) string {
+	sum := sha256.Sum256([]byte(pageURL))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Recorder fetches through Next and saves every response it gets, a later replay serves them back
+type Recorder struct {
+	Dir  string
+	Next Fetcher
+}
+
+func (r *Recorder) Fetch(ctx context.Context, pageURL string, opts FetchOptions) (*Response, error) {
+	res, err := r.Next.Fetch(ctx, pageURL, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(recording{
+		URL:        pageURL,
+		FinalURL:   res.URL,
+		Status:     res.Status,
+		StatusCode: res.StatusCode,
+		Header:     res.Header,
+		Redirects:  res.Redirects,
+		Body:       res.Body,
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error encoding the recording: %w", err)
+	}
+
+	if err := os.MkdirAll(r.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating the recordings directory: %w", err)
+	}
+	if err := os.WriteFile(recordingPath(r.Dir, pageURL), data, 0o644); err != nil {
+		return nil, fmt.Errorf("error saving the recording: %w", err)
+	}
+
+	return res, nil
+}
+
+// Replayer answers from a Recorder's directory and never touches the network
+type Replayer struct {
+	Dir string
+}
+
+func (r *Replayer) Fetch(ctx context.Context, pageURL string, opts FetchOptions) (*Response, error) {
+	data, err := os.ReadFile(recordingPath(r.Dir, pageURL))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNoRecording, pageURL)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading the recording: %w", err)
+	}
+
+	var rec recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("error decoding the recording for %s: %w", pageURL, err)
+	}
+	// the same limit as the live fetch, a replay shouldn't accept what the network wouldn't
+	if limit := opts.maxSize(); int64(len(rec.Body)) > limit {
+		return nil, fmt.Errorf("response from %s is larger than %d bytes", pageURL, limit)
+	}
+
+	return &Response{
+		URL:        rec.FinalURL,
+		Status:     rec.Status,
+		StatusCode: rec.StatusCode,
+		Header:     rec.Header,
+		Redirects:  rec.Redirects,
+		Body:       rec.Body,
+	}, nil
+}
@@ -0,0 +1,98 @@
+package rss
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecordReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old":
+			http.Redirect(w, r, "/feed.xml?v=2", http.StatusMovedPermanently)
+		case "/feed.xml":
+			w.Header().Set("Content-Type", "application/rss+xml")
+			w.Header().Set("ETag", `"abc"`)
+			w.Write([]byte(streamFeed))
+		default:
+			http.Error(w, "gone", http.StatusGone)
+		}
+	}))
+	dir := t.TempDir()
+	defer SetFetcher(HTTPFetcher{})
+
+	if err := ConfigureFetcher(ModeRecord, dir); err != nil {
+		t.Fatal(err)
+	}
+	recorded := map[string]*Response{}
+	for _, path := range []string{"/old", "/missing"} {
+		res, err := Fetch(context.Background(), server.URL+path, FetchOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		recorded[path] = res
+	}
+	server.Close()
+
+	// the server is gone, every answer has to come from the recordings
+	if err := ConfigureFetcher(ModeReplay, dir); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range recorded {
+		got, err := Fetch(context.Background(), server.URL+path, FetchOptions{})
+		if err != nil {
+			t.Fatalf("replaying %s: %v", path, err)
+		}
+		if got.URL != want.URL || got.Status != want.Status || got.StatusCode != want.StatusCode || string(got.Body) != string(want.Body) {
+			t.Errorf("%s: replayed %s %s (%d bytes), recorded %s %s (%d bytes)",
+				path, got.URL, got.Status, len(got.Body), want.URL, want.Status, len(want.Body))
+		}
+		if got.Header.Get("Content-Type") != want.Header.Get("Content-Type") || got.Header.Get("ETag") != want.Header.Get("ETag") {
+			t.Errorf("%s: replayed headers %v, recorded %v", path, got.Header, want.Header)
+		}
+		if len(got.Redirects) != len(want.Redirects) {
+			t.Errorf("%s: replayed redirects %+v, recorded %+v", path, got.Redirects, want.Redirects)
+		}
+	}
+	if res := recorded["/old"]; !strings.HasSuffix(res.URL, "/feed.xml?v=2") || len(res.Redirects) != 1 {
+		t.Errorf("the redirect wasn't recorded: %s after %+v", res.URL, res.Redirects)
+	}
+
+	// the feed functions go through the replay too
+	feed, err := FetchFeed(context.Background(), server.URL+"/old")
+	if err != nil || feed.Channel.Title != "Notes & more" {
+		t.Errorf("FetchFeed from the replay: %q, %v", feed.Channel.Title, err)
+	}
+}
+
+func TestReplayMissingRecording(t *testing.T) {
+	replayer := &Replayer{Dir: t.TempDir()}
+	_, err := replayer.Fetch(context.Background(), "https://never.example.com/feed.xml", FetchOptions{})
+	if !errors.Is(err, ErrNoRecording) || !strings.Contains(err.Error(), "https://never.example.com/feed.xml") {
+		t.Errorf("err = %v, want ErrNoRecording naming the url", err)
+	}
+}
+
+func TestConfigureFetcher(t *testing.T) {
+	defer SetFetcher(HTTPFetcher{})
+	for _, tt := range []struct {
+		mode, dir string
+		wantErr   bool
+	}{
+		{ModeLive, "", false},
+		{ModeRecord, "recordings", false},
+		{ModeReplay, "recordings", false},
+		{ModeRecord, "", true},
+		{ModeReplay, "", true},
+		{"rewind", "recordings", true},
+	} {
+		err := ConfigureFetcher(tt.mode, tt.dir)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ConfigureFetcher(%q, %q) = %v, want error %v", tt.mode, tt.dir, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+/* Selectors describe how to read a page that has no RSS (a changelog, a status page) as if it had one.
+Item matches every entry on the page, the others are looked up inside each entry. Only Item is required:
+without Title we use the entry's text, without Link the first <a href> in it. */
+
+type Selectors struct {
+	Item    string
+	Title   string
+	Link    string
+	Date    string
+	Summary string
+}
+
+// Human-ish date formats that show up on pages, tried after the RSS ones
+var pageDateFormats = []string{
+	time.RFC1123Z, time.RFC1123, time.RFC822, time.RFC3339, "2006-01-02T15:04:05Z",
+	"2006-01-02 15:04:05", "2006-01-02", "January 2, 2006", "Jan 2, 2006", "2 January 2006", "2 Jan 2006", "02/01/2006",
+}
+
+// ScrapeHTML downloads pageURL and turns it into the same RSSFeed that FetchFeed returns, using sel to find the items
+
+func ScrapeHTML(ctx context.Context, pageURL string, sel Selectors, opts FetchOptions) (*RSSFeed, error) {
+	data, err := FetchPageWith(ctx, pageURL, opts)
+	if err != nil {
+		return &RSSFeed{}, err
+	}
+	return ParseHTML(data, pageURL, sel)
+}
+
+// ParseHTML does the work of ScrapeHTML on a page that's already been downloaded
+
+func ParseHTML(data []byte, pageURL string, sel Selectors) (*RSSFeed, error) {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return &RSSFeed{}, fmt.Errorf("invalid page url: %w", err)
+	}
+
+	itemSel, err := cascadia.Compile(sel.Item)
+	if err != nil {
+		return &RSSFeed{}, fmt.Errorf("invalid item selector %q: %w", sel.Item, err)
+	}
+	titleSel, err := compileOptional(sel.Title)
+	if err != nil {
+		return &RSSFeed{}, err
+	}
+	linkSel, err := compileOptional(sel.Link)
+	if err != nil {
+		return &RSSFeed{}, err
+	}
+	dateSel, err := compileOptional(sel.Date)
+	if err != nil {
+		return &RSSFeed{}, err
+	}
+	summarySel, err := compileOptional(sel.Summary)
+	if err != nil {
+		return &RSSFeed{}, err
+	}
+
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return &RSSFeed{}, fmt.Errorf("error parsing the page html: %w", err)
+	}
+
+	feed := RSSFeed{}
+	feed.Channel.Link = pageURL
+	if title := cascadia.Query(doc, cascadia.MustCompile("title")); title != nil {
+		feed.Channel.Title = clean(textOf(title))
+	}
+
+	for _, node := range cascadia.QueryAll(doc, itemSel) {
+		item := RSSItem{}
+
+		if titleNode := queryIn(node, titleSel); titleNode != nil {
+			item.Title = clean(textOf(titleNode))
+		} else {
+			item.Title = clean(textOf(node))
+		}
+
+		item.Link = linkOf(node, linkSel, base)
+
+		if dateNode := queryIn(node, dateSel); dateNode != nil {
+			item.PubDate = normalizeDate(dateNode)
+		}
+
+		if summaryNode := queryIn(node, summarySel); summaryNode != nil {
+			item.Description = innerHTML(summaryNode)
+		}
+
+		// an entry without a link can't become a post (posts.url is required), skip it
+		if item.Title == "" || item.Link == "" {
+			continue
+		}
+		feed.Channel.Item = append(feed.Channel.Item, item)
+	}
+
+	return &feed, nil
+}
+
+func compileOptional(selector string) (cascadia.Sel, error) {
+	if selector == "" {
+		return nil, nil
+	}
+	compiled, err := cascadia.Parse(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector %q: %w", selector, err)
+	}
+	return compiled, nil
+}
+
+func queryIn(n *html.Node, sel cascadia.Sel) *html.Node {
+	if sel == nil {
+		return nil
+	}
+	return cascadia.Query(n, sel)
+}
+
+// linkOf finds the entry's link: the matched element's href, or the first <a href> inside it (or inside the entry)
+func linkOf(n *html.Node, sel cascadia.Sel, base *url.URL) string {
+	target := n
+	if found := queryIn(n, sel); found != nil {
+		target = found
+	}
+
+	href := attrOf(target, "href")
+	if href == "" {
+		if a := cascadia.Query(target, cascadia.MustCompile("a[href]")); a != nil {
+			href = attrOf(a, "href")
+		}
+	}
+	if href == "" {
+		return ""
+	}
+
+	abs, err := base.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return ""
+	}
+	return abs.String()
+}
+
+// normalizeDate reads a <time datetime> or the element's text and rewrites it as RFC1123Z, which scrapeFeeds understands
+func normalizeDate(n *html.Node) string {
+	raw := attrOf(n, "datetime")
+	if raw == "" {
+		raw = clean(textOf(n))
+	}
+	for _, format := range pageDateFormats {
+		if t, err := time.Parse(format, raw); err == nil {
+			return t.Format(time.RFC1123Z)
+		}
+	}
+	return raw
+}
+
+func innerHTML(n *html.Node) string {
+	var b bytes.Buffer
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		html.Render(&b, c)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func textOf(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	if n.Type == html.ElementNode && (n.DataAtom == atom.Script || n.DataAtom == atom.Style) {
+		return ""
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textOf(c))
+	}
+	return b.String()
+}
+
+func attrOf(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// clean collapses all the whitespace html indentation leaves in text
+func clean(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,72 @@
+package rss
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestScrapeHTML(t *testing.T) {
+	page, err := os.ReadFile("testdata/changelog.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write(page)
+	}))
+	defer server.Close()
+	pageURL := server.URL + "/docs/changelog"
+
+	feed, err := ScrapeHTML(context.Background(), pageURL, Selectors{Item: "li.release", Title: "h3", Date: "li > :nth-child(2)", Summary: ".notes"}, FetchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.Channel.Title != "Tool changelog" || feed.Channel.Link != pageURL {
+		t.Errorf("channel = %q at %q", feed.Channel.Title, feed.Channel.Link)
+	}
+
+	want := []RSSItem{
+		{Title: "Version 2.0", Link: server.URL + "/releases/2.0", PubDate: "Fri, 01 Mar 2024 00:00:00 +0000", Description: "<p>New <b>storage</b> engine.</p>"},
+		{Title: "Version 1.9", Link: server.URL + "/docs/1.9.html", PubDate: "Mon, 15 Jan 2024 00:00:00 +0000"},
+		// a date we can't read is passed on as it is
+		{Title: "Version 1.8", Link: "https://mirror.example.com/1.8", PubDate: "sometime last year"},
+	}
+	if len(feed.Channel.Item) != len(want) {
+		t.Fatalf("got %d items, want %d (the draft without a link is skipped): %+v", len(feed.Channel.Item), len(want), feed.Channel.Item)
+	}
+	for i, item := range feed.Channel.Item {
+		if item != want[i] {
+			t.Errorf("item %d =\n%+v\nwant\n%+v", i, item, want[i])
+		}
+	}
+}
+
+func TestScrapeHTMLOnlyItemSelector(t *testing.T) {
+	page, err := os.ReadFile("testdata/changelog.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// without the optional selectors the title is the entry's text and the link its first <a href>
+	feed, err := ParseHTML(page, "https://tool.example.com/docs/changelog", Selectors{Item: "li.release"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(feed.Channel.Item) != 3 {
+		t.Fatalf("got %d items, want 3", len(feed.Channel.Item))
+	}
+	first := feed.Channel.Item[0]
+	if first.Title != "Version 2.0 March 1 New storage engine." || first.Link != "https://tool.example.com/releases/2.0" || first.PubDate != "" {
+		t.Errorf("first item = %+v", first)
+	}
+
+	if _, err := ParseHTML(page, "https://tool.example.com/", Selectors{Item: "li[", Title: "h3"}); err == nil {
+		t.Error("an invalid item selector should fail")
+	}
+	if _, err := ParseHTML(page, "https://tool.example.com/", Selectors{Item: "li", Date: ":nope"}); err == nil {
+		t.Error("an invalid date selector should fail")
+	}
+}
@@ -0,0 +1,153 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"strings"
+)
+
+/* Report is what gator inspect prints: what the server said, what the document looks like, and what
+gator would make of it. Problems that don't stop the feed from working (a date we can't read, an item
+without a link) are Warnings, a document we can't parse at all is ParseError. */
+
+type Report struct {
+	*Response
+	Format     string
+	Charset    string
+	Feed       *RSSFeed
+	ParseError error
+	Warnings   []string
+}
+
+/* Inspect fetches feedURL through the same fetcher, credentials and network settings as every fetch, but
+as a whole Response with the MaxBodySize cap (agg streams feeds instead), and keeps everything it sees
+along the way. A 404 or 500 is part of the report, not an error. */
+
+func Inspect(ctx context.Context, feedURL string, opts FetchOptions) (*Report, error) {
+	res, err := Fetch(ctx, feedURL, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Response: res}
+	report.Format, report.Charset = sniff(res)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		report.ParseError = fmt.Errorf("not parsed, the server answered %s", res.Status)
+		return report, nil
+	}
+
+	switch report.Format {
+	case "RSS 2.0":
+	case "Atom", "RSS 1.0 (RDF)", "JSON Feed":
+		report.Warnings = append(report.Warnings, report.Format+" is not supported yet, only RSS 2.0 items are read")
+	case "HTML":
+		report.Warnings = append(report.Warnings, "this is an HTML page, not a feed (use addpage to scrape it, or look for a <link rel=\"alternate\"> feed on it)")
+	}
+
+	if charset := strings.ToLower(report.Charset); charset != "" && charset != "utf-8" && charset != "us-ascii" {
+		report.Warnings = append(report.Warnings, "charset "+report.Charset+" is not UTF-8, decoding will likely fail")
+	}
+
+	report.Feed, report.ParseError = ParseFeed(res.Body)
+	if report.ParseError != nil {
+		return report, nil
+	}
+
+	report.Warnings = append(report.Warnings, itemWarnings(report.Feed)...)
+	return report, nil
+}
+
+// sniff guesses the format from the first element of the document, and the charset from the headers or the XML declaration
+func sniff(res *Response) (format, charset string) {
+	if _, params, err := mime.ParseMediaType(res.Header.Get("Content-Type")); err == nil {
+		charset = params["charset"]
+	}
+
+	trimmed := bytes.TrimSpace(res.Body)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		return "JSON Feed", charset
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(trimmed))
+	decoder.Strict = false
+	decoder.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		if charset == "" {
+			charset = label
+		}
+		return input, nil
+	}
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "unknown", charset
+		}
+		switch t := token.(type) {
+		case xml.ProcInst:
+			if t.Target == "xml" && charset == "" {
+				charset = declaredEncoding(string(t.Inst))
+			}
+		case xml.StartElement:
+			switch strings.ToLower(t.Name.Local) {
+			case "rss":
+				return "RSS 2.0", charset
+			case "feed":
+				return "Atom", charset
+			case "rdf":
+				return "RSS 1.0 (RDF)", charset
+			case "html":
+				return "HTML", charset
+			}
+			return "unknown (<" + t.Name.Local + ">)", charset
+		}
+	}
+}
+
+func declaredEncoding(inst string) string {
+	_, after, ok := strings.Cut(inst, "encoding=")
+	if !ok || len(after) < 2 {
+		return ""
+	}
+	quote := after[0]
+	value, _, _ := strings.Cut(after[1:], string(quote))
+	return value
+}
+
+// itemWarnings checks every item for the things that make scrapeFeeds skip it or store it badly
+func itemWarnings(feed *RSSFeed) []string {
+	var warnings []string
+	if len(feed.Channel.Item) == 0 {
+		warnings = append(warnings, "the feed has no items")
+	}
+
+	for i, item := range feed.Channel.Item {
+		name := fmt.Sprintf("item %d", i+1)
+		if item.Title != "" {
+			name += fmt.Sprintf(" (%q)", item.Title)
+		}
+
+		if item.Title == "" {
+			warnings = append(warnings, name+": missing title")
+		}
+		if item.Link == "" {
+			warnings = append(warnings, name+": missing link, it can't be stored")
+		} else if u, err := url.Parse(item.Link); err != nil || !u.IsAbs() {
+			warnings = append(warnings, name+": relative link "+item.Link)
+		}
+		if item.GUID == "" {
+			warnings = append(warnings, name+": missing guid")
+		}
+		if item.PubDate == "" {
+			warnings = append(warnings, name+": missing pubDate")
+		} else if _, err := ParseDate(item.PubDate); err != nil {
+			warnings = append(warnings, name+": unreadable pubDate "+item.PubDate)
+		}
+	}
+	return warnings
+}
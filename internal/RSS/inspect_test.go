@@ -0,0 +1,66 @@
+package rss
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSniff(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		format      string
+		charset     string
+	}{
+		{"rss", "application/rss+xml", `<?xml version="1.0"?><rss version="2.0"></rss>`, "RSS 2.0", ""},
+		{"charset from the header", "text/xml; charset=ISO-8859-1", `<?xml version="1.0" encoding="utf-8"?><rss/>`, "RSS 2.0", "ISO-8859-1"},
+		{"charset from the declaration", "text/xml", `<?xml version="1.0" encoding="windows-1252"?><rss/>`, "RSS 2.0", "windows-1252"},
+		{"atom", "", "\n  <feed xmlns=\"http://www.w3.org/2005/Atom\"></feed>", "Atom", ""},
+		{"rdf", "", `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"></rdf:RDF>`, "RSS 1.0 (RDF)", ""},
+		{"json feed", "application/feed+json", `{"version": "https://jsonfeed.org/version/1.1"}`, "JSON Feed", ""},
+		{"html", "text/html; charset=utf-8", `<!DOCTYPE html><html><head></head></html>`, "HTML", "utf-8"},
+		{"other xml", "", `<opml version="2.0"></opml>`, "unknown (<opml>)", ""},
+		{"not xml", "", `just text`, "unknown", ""},
+	}
+	for _, tt := range tests {
+		res := &Response{Header: http.Header{"Content-Type": {tt.contentType}}, Body: []byte(tt.body)}
+		format, charset := sniff(res)
+		if format != tt.format || charset != tt.charset {
+			t.Errorf("%s: sniff = %q, %q, want %q, %q", tt.name, format, charset, tt.format, tt.charset)
+		}
+	}
+}
+
+func TestItemWarnings(t *testing.T) {
+	tests := []struct {
+		name string
+		item string
+		want []string
+	}{
+		{"fine", `<title>Fine</title><link>https://example.com/1</link><guid>1</guid><pubDate>Mon, 02 Jan 2006 15:04:05 GMT</pubDate>`, nil},
+		{"bad date", `<title>Late</title><link>https://example.com/2</link><guid>2</guid><pubDate>sometime soon</pubDate>`,
+			[]string{`item 1 ("Late"): unreadable pubDate sometime soon`}},
+		{"no link or guid", `<title>Lost</title><pubDate>Mon, 02 Jan 2006 15:04:05 GMT</pubDate>`,
+			[]string{`item 1 ("Lost"): missing link, it can't be stored`, `item 1 ("Lost"): missing guid`}},
+		{"relative link", `<title>Near</title><link>/posts/3</link><guid>3</guid><pubDate>Mon, 02 Jan 2006 15:04:05 GMT</pubDate>`,
+			[]string{`item 1 ("Near"): relative link /posts/3`}},
+		{"no title or date", `<link>https://example.com/4</link><guid>4</guid>`,
+			[]string{"item 1: missing title", "item 1: missing pubDate"}},
+	}
+	for _, tt := range tests {
+		feed, err := ParseFeed([]byte(`<rss version="2.0"><channel><title>T</title><item>` + tt.item + `</item></channel></rss>`))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got := itemWarnings(feed)
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("%s: warnings\n%s\nwant\n%s", tt.name, strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+		}
+	}
+
+	if got := itemWarnings(&RSSFeed{}); len(got) != 1 || got[0] != "the feed has no items" {
+		t.Errorf("empty feed: %q", got)
+	}
+}
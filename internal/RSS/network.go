@@ -0,0 +1,136 @@
+package rss
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+)
+
+/* Network is how gator reaches the outside world when the defaults don't work, like behind an office
+proxy with a corporate CA. Proxy can be http://, https:// or socks5:// and applies to every fetch (without
+it the usual HTTP_PROXY/HTTPS_PROXY env vars still work). CABundles are PEM files trusted on top of the
+system roots. */
+
+type Network struct {
+	Proxy     string
+	CABundles []string
+}
+
+// DirectProxy as a feed's proxy skips the global proxy for that feed
+const DirectProxy = "direct"
+
+// clientKey is what makes two fetches need different clients
+type clientKey struct {
+	proxy      string
+	serverName string
+}
+
+var network = struct {
+	sync.Mutex
+	proxy   string
+	roots   *x509.CertPool
+	clients map[clientKey]*http.Client
+}{clients: map[clientKey]*http.Client{}}
+
+// ConfigureNetwork loads the CA bundles and sets the global proxy, call it once at startup before fetching
+
+func ConfigureNetwork(n Network) error {
+	if n.Proxy != "" {
+		if _, err := parseProxy(n.Proxy); err != nil {
+			return err
+		}
+	}
+
+	var roots *x509.CertPool
+	if len(n.CABundles) > 0 {
+		var err error
+		roots, err = x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		for _, bundle := range n.CABundles {
+			pem, err := os.ReadFile(bundle)
+			if err != nil {
+				return fmt.Errorf("error reading CA bundle: %w", err)
+			}
+			if !roots.AppendCertsFromPEM(pem) {
+				return fmt.Errorf("no certificates found in CA bundle %s", bundle)
+			}
+		}
+	}
+
+	network.Lock()
+	defer network.Unlock()
+	network.proxy = n.Proxy
+	network.roots = roots
+	network.clients = map[clientKey]*http.Client{}
+	return nil
+}
+
+func parseProxy(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %q: %w", raw, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return u, nil
+	}
+	return nil, fmt.Errorf("invalid proxy %q: scheme must be http, https or socks5", raw)
+}
+
+// Client is the client a fetch with opts goes through, for requests that aren't fetches (like WebSub's hub requests)
+
+func Client(opts FetchOptions) (*http.Client, error) {
+	return clientFor(opts)
+}
+
+/* clientFor returns the client for a fetch's proxy and TLS server name. Clients are cached, so
+connections to the same site get reused like they would with a single shared client. */
+
+func clientFor(opts FetchOptions) (*http.Client, error) {
+	network.Lock()
+	defer network.Unlock()
+
+	proxy := opts.Proxy
+	if proxy == "" {
+		proxy = network.proxy
+	}
+	key := clientKey{proxy: proxy, serverName: opts.ServerName}
+
+	if key == (clientKey{}) && network.roots == nil {
+		return httpClient, nil
+	}
+	if client, ok := network.clients[key]; ok {
+		return client, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	switch proxy {
+	case "":
+		// keep ProxyFromEnvironment from the default transport
+	case DirectProxy:
+		transport.Proxy = nil
+	default:
+		u, err := parseProxy(proxy)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+
+	if network.roots != nil || opts.ServerName != "" {
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs:    network.roots,
+			ServerName: opts.ServerName,
+		}
+	}
+
+	client := &http.Client{Timeout: FetchTimeout, Transport: transport}
+	network.clients[key] = client
+	return client, nil
+}
@@ -1,65 +1,194 @@
 package rss
 
 import (
+	"bytes"
 	"context"
-	"encoding/xml"
-	"fmt"
-	"html"
-	"io"
-	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type RSSFeed struct {
 	Channel struct {
-		Title       string    `xml:"title"`
-		Link        string    `xml:"link"`
-		Description string    `xml:"description"`
-		Item        []RSSItem `xml:"item"`
+		// DecodeFeed reads these by hand, a new field has to be added there too
+		Title string `xml:"title"`
+		// <atom:link> has to come before Link, otherwise the empty atom links would overwrite the <link> text
+		AtomLinks   []AtomLink `xml:"http://www.w3.org/2005/Atom link"`
+		Link        string     `xml:"link"`
+		Description string     `xml:"description"`
+		Generator   string     `xml:"generator"`
+		Language    string     `xml:"language"`
+		// the feed's picture, feeds name it in any of these, see ImageURL
+		Image       RSSImage    `xml:"image"`
+		ITunesImage ITunesImage `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+		AtomLogo    string      `xml:"http://www.w3.org/2005/Atom logo"`
+		AtomIcon    string      `xml:"http://www.w3.org/2005/Atom icon"`
+		Item        []RSSItem   `xml:"item"`
 	} `xml:"channel"`
 }
 
+// RSSImage is the channel's <image>, only the picture's url matters to us
+type RSSImage struct {
+	URL string `xml:"url"`
+}
+
+// ITunesImage is the podcast artwork, <itunes:image href="...">
+type ITunesImage struct {
+	Href string `xml:"href,attr"`
+}
+
+// AtomLink is an <atom:link> in an RSS channel, feeds use them to point at themselves (rel="self") and their WebSub hub (rel="hub")
+type AtomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
 type RSSItem struct {
 	Title       string `xml:"title"`
 	Link        string `xml:"link"`
 	Description string `xml:"description"`
 	PubDate     string `xml:"pubDate"`
+	GUID        string `xml:"guid"`
+}
+
+// The date formats feeds actually use for pubDate, in the order we try them
+var dateFormats = []string{time.RFC1123Z, time.RFC1123, time.RFC822, time.RFC3339, "2006-01-02T15:04:05Z"}
+
+// ParseDate reads an item's pubDate, an error means none of the formats matched
+
+func ParseDate(value string) (time.Time, error) {
+	var err error
+	for _, format := range dateFormats {
+		var t time.Time
+		t, err = time.Parse(format, strings.TrimSpace(value))
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
 }
 
 func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
+	return FetchFeedWith(ctx, feedURL, FetchOptions{})
+}
 
-	request, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+// FetchFeedWith is FetchFeed with per-feed options, like the credentials of a private feed
+
+func FetchFeedWith(ctx context.Context, feedURL string, opts FetchOptions) (*RSSFeed, error) {
+
+	data, err := FetchPageWith(ctx, feedURL, opts)
 	if err != nil {
-		return &RSSFeed{}, fmt.Errorf("error in the request: %w", err)
+		return &RSSFeed{}, err
 	}
 
-	request.Header.Set("User-Agent", "gator")
+	return ParseFeed(data)
+}
+
+// ParseFeed does the decoding half of FetchFeed, for feed documents that didn't come from a GET (like a WebSub push)
+
+func ParseFeed(data []byte) (*RSSFeed, error) {
 
-	client := &http.Client{}
-	res, err := client.Do(request)
+	var items []RSSItem
+	feed, err := DecodeFeed(bytes.NewReader(data), 0, func(item RSSItem) error {
+		items = append(items, item)
+		return nil
+	})
 	if err != nil {
 		return &RSSFeed{}, err
 	}
-	defer res.Body.Close()
+	feed.Channel.Item = items
 
-	data, err := io.ReadAll(res.Body)
-	if err != nil {
-		return &RSSFeed{}, fmt.Errorf("error reading the data from the body: %w", err)
+	return feed, nil
+}
+
+/* ImageURL returns the feed's picture: <image>, then podcast artwork, then the Atom logo and icon.
+Relative URLs are resolved against the channel link. It returns "" if the feed has none. */
+
+func (f *RSSFeed) ImageURL() string {
+	channel := f.Channel
+	for _, image := range []string{channel.Image.URL, channel.ITunesImage.Href, channel.AtomLogo, channel.AtomIcon} {
+		image = strings.TrimSpace(image)
+		if image == "" {
+			continue
+		}
+		base, err := url.Parse(channel.Link)
+		if err != nil {
+			return image
+		}
+		ref, err := base.Parse(image)
+		if err != nil {
+			return ""
+		}
+		return ref.String()
 	}
+	return ""
+}
 
-	rss := RSSFeed{}
-	err = xml.Unmarshal(data, &rss)
-	if err != nil {
-		return &RSSFeed{}, fmt.Errorf("error in decoding the read data: %w", err)
+// Hub returns the WebSub hub the feed advertises, or "" if it doesn't have one
+
+func (f *RSSFeed) Hub() string {
+	return f.atomLink("hub")
+}
+
+// Self returns the URL the feed says it lives at, which is the topic we subscribe to at the hub
+
+func (f *RSSFeed) Self() string {
+	return f.atomLink("self")
+}
+
+func (f *RSSFeed) atomLink(rel string) string {
+	for _, link := range f.Channel.AtomLinks {
+		if link.Rel == rel {
+			return link.Href
+		}
 	}
+	return ""
+}
+
+/* NextPage returns the URL of the page with older items, for walking a feed's history: RFC 5005's
+rel="prev-archive" for archived feeds, or rel="next" for paged feeds. Relative links are resolved
+against pageURL. It returns "" on the last page. */
 
-	rss.Channel.Title = html.UnescapeString(rss.Channel.Title)
-	rss.Channel.Description = html.UnescapeString(rss.Channel.Description)
-	rss.Channel.Link = html.UnescapeString(rss.Channel.Link)
+func (f *RSSFeed) NextPage(pageURL string) string {
+	next := f.atomLink("prev-archive")
+	if next == "" {
+		next = f.atomLink("next")
+	}
+	if next == "" {
+		return ""
+	}
 
-	for i := range rss.Channel.Item {
-		rss.Channel.Item[i].Title = html.UnescapeString(rss.Channel.Item[i].Title)
-		rss.Channel.Item[i].Description = html.UnescapeString(rss.Channel.Item[i].Description)
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return next
+	}
+	ref, err := base.Parse(next)
+	if err != nil {
+		return ""
 	}
+	return ref.String()
+}
+
+// IsWordPress is true for feeds WordPress generated, those can be paged with ?paged=N even without any links
 
-	return &rss, nil
+func (f *RSSFeed) IsWordPress() bool {
+	return strings.Contains(strings.ToLower(f.Channel.Generator), "wordpress")
+}
+
+// WordPressPage returns page n of a WordPress feed, n = 1 is the feed itself
+
+func WordPressPage(feedURL string, n int) (string, error) {
+	u, err := url.Parse(feedURL)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	if n <= 1 {
+		query.Del("paged")
+	} else {
+		query.Set("paged", strconv.Itoa(n))
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
 }
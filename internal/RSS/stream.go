@@ -0,0 +1,213 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+)
+
+/* Archive feeds can be tens of megabytes. FetchFeed holds the whole body and every item in memory,
+StreamFeed reads the feed as it comes in and hands over one item at a time, so the body is never held
+whole. It stops after maxItems, the rest of the feed is never read. */
+
+const (
+	atomNamespace   = "http://www.w3.org/2005/Atom"
+	iTunesNamespace = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+	// DefaultMaxItems is the item cap when the caller doesn't pick one
+	DefaultMaxItems = 1000
+	// MaxStreamSize caps a streamed feed, it's much bigger than MaxBodySize since the body is never held whole
+	MaxStreamSize = 512 << 20 // 512 MB
+)
+
+/* Opener is a Fetcher that can hand out the body as it arrives. The live fetcher does, record and replay
+don't: StreamFeed reads their whole response instead, with the MaxStreamSize cap and not MaxBodySize. */
+
+type Opener interface {
+	Open(ctx context.Context, pageURL string, opts FetchOptions) (io.ReadCloser, error)
+}
+
+// StreamFeed fetches feedURL and calls each for every item, see DecodeFeed for what it returns
+
+func StreamFeed(ctx context.Context, feedURL string, opts FetchOptions, maxItems int, each func(RSSItem) error) (*RSSFeed, error) {
+	var body io.ReadCloser
+	if opener, ok := currentFetcher().(Opener); ok {
+		var err error
+		body, err = opener.Open(ctx, feedURL, opts)
+		if err != nil {
+			return &RSSFeed{}, err
+		}
+	} else {
+		// fetchers that only have whole responses still work, they just don't save any memory
+		opts.MaxSize = MaxStreamSize
+		data, err := FetchPageWith(ctx, feedURL, opts)
+		if err != nil {
+			return &RSSFeed{}, err
+		}
+		body = io.NopCloser(bytes.NewReader(data))
+	}
+	defer body.Close()
+
+	return DecodeFeed(body, maxItems, each)
+}
+
+/* DecodeFeed reads an RSS document token by token. Items go to each as soon as they're decoded and are
+not kept, so the returned feed only has the channel's own fields (title, link, atom links...). Those
+usually come before the items, anything after the item cap is lost. maxItems <= 0 means no cap.
+An error from each stops the decoding and is returned as it is. */
+
+func DecodeFeed(r io.Reader, maxItems int, each func(RSSItem) error) (*RSSFeed, error) {
+	decoder := xml.NewDecoder(r)
+	feed := RSSFeed{}
+	count := 0
+	// depth counts open elements: 1 is the root (<rss>), 2 is <channel>, 3 are the channel's children
+	depth := 0
+	inChannel := false
+	sawRoot := false
+
+decode:
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return &RSSFeed{}, fmt.Errorf("error in decoding the read data: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.EndElement:
+			depth--
+			if depth == 1 {
+				inChannel = false
+			}
+			continue
+		case xml.StartElement:
+			depth++
+			sawRoot = true
+			if depth == 2 && t.Name.Local == "channel" {
+				inChannel = true
+				continue
+			}
+			if depth != 3 || !inChannel {
+				continue
+			}
+
+			// DecodeElement eats the end element too, so this element never gets its own EndElement
+			depth--
+			channel := &feed.Channel
+			switch {
+			case t.Name.Local == "item":
+				item := RSSItem{}
+				if err := decoder.DecodeElement(&item, &t); err != nil {
+					return &RSSFeed{}, fmt.Errorf("error in decoding item %d: %w", count+1, err)
+				}
+				item.Title = html.UnescapeString(item.Title)
+				item.Description = html.UnescapeString(item.Description)
+				if err := each(item); err != nil {
+					return &feed, err
+				}
+				count++
+				if maxItems > 0 && count >= maxItems {
+					break decode
+				}
+			case t.Name.Space == iTunesNamespace && t.Name.Local == "image":
+				err = decoder.DecodeElement(&channel.ITunesImage, &t)
+			case t.Name.Space == atomNamespace && t.Name.Local == "logo":
+				err = decoder.DecodeElement(&channel.AtomLogo, &t)
+			case t.Name.Space == atomNamespace && t.Name.Local == "icon":
+				err = decoder.DecodeElement(&channel.AtomIcon, &t)
+			case t.Name.Local == "image":
+				err = decoder.DecodeElement(&channel.Image, &t)
+			case t.Name.Space == atomNamespace && t.Name.Local == "link":
+				link := AtomLink{}
+				err = decoder.DecodeElement(&link, &t)
+				channel.AtomLinks = append(channel.AtomLinks, link)
+			case t.Name.Local == "title":
+				err = decoder.DecodeElement(&channel.Title, &t)
+			case t.Name.Local == "link":
+				err = decoder.DecodeElement(&channel.Link, &t)
+			case t.Name.Local == "description":
+				err = decoder.DecodeElement(&channel.Description, &t)
+			case t.Name.Local == "generator":
+				err = decoder.DecodeElement(&channel.Generator, &t)
+			case t.Name.Local == "language":
+				err = decoder.DecodeElement(&channel.Language, &t)
+			default:
+				err = decoder.Skip()
+			}
+			if err != nil {
+				return &RSSFeed{}, fmt.Errorf("error in decoding <%s>: %w", t.Name.Local, err)
+			}
+		}
+	}
+
+	if !sawRoot {
+		// an empty document, xml.Unmarshal fails on those too
+		return &RSSFeed{}, fmt.Errorf("error in decoding the read data: %w", io.EOF)
+	}
+
+	feed.Channel.Title = html.UnescapeString(feed.Channel.Title)
+	feed.Channel.Description = html.UnescapeString(feed.Channel.Description)
+	feed.Channel.Link = html.UnescapeString(feed.Channel.Link)
+
+	return &feed, nil
+}
+
+// Open is the streaming side of HTTPFetcher.Fetch: same request, but the body is returned unread
+
+func (HTTPFetcher) Open(ctx context.Context, pageURL string, opts FetchOptions) (io.ReadCloser, error) {
+	if strings.HasPrefix(pageURL, "file://") {
+		if !opts.AllowFile {
+			return nil, fmt.Errorf("file urls are only allowed for feeds: %s", pageURL)
+		}
+		u, err := url.Parse(pageURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file url: %w", err)
+		}
+		file, err := os.Open(u.Path)
+		if err != nil {
+			return nil, err
+		}
+		return &limitedBody{ReadCloser: file, name: u.Path, left: MaxStreamSize}, nil
+	}
+
+	res, err := get(ctx, pageURL, opts)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		res.Body.Close()
+		return nil, &StatusError{URL: pageURL, Status: res.Status, StatusCode: res.StatusCode}
+	}
+	return &limitedBody{ReadCloser: res.Body, name: pageURL, left: MaxStreamSize}, nil
+}
+
+// limitedBody fails once more than left bytes have been read, instead of quietly cutting the feed short
+type limitedBody struct {
+	io.ReadCloser
+	name string
+	left int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.left <= 0 {
+		// the body may end right at the limit, only a byte past it is too much
+		if n, _ := b.ReadCloser.Read(make([]byte, 1)); n == 0 {
+			return 0, io.EOF
+		}
+		return 0, fmt.Errorf("%s is larger than %d bytes", b.name, MaxStreamSize)
+	}
+	if int64(len(p)) > b.left {
+		p = p[:b.left]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.left -= int64(n)
+	return n, err
+}
@@ -0,0 +1,167 @@
+package rss
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const streamFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd">
+<channel>
+  <title>Notes &amp;amp; more</title>
+  <link>https://notes.example.com/</link>
+  <description>A blog</description>
+  <language>en</language>
+  <atom:link rel="self" href="https://notes.example.com/feed.xml"/>
+  <atom:link rel="prev-archive" href="/feed/2023.xml"/>
+  <atom:logo>/logo.png</atom:logo>
+  <item><title>First &amp;amp; best</title><link>https://notes.example.com/1</link><description>&lt;p&gt;one&lt;/p&gt;</description></item>
+  <item><title>Second</title><link>https://notes.example.com/2</link><guid>2</guid></item>
+  <item><title>Third</title><link>https://notes.example.com/3</link></item>
+  <unknown><nested>skipped</nested></unknown>
+</channel>
+</rss>`
+
+func TestDecodeFeed(t *testing.T) {
+	var items []RSSItem
+	feed, err := DecodeFeed(strings.NewReader(streamFeed), 0, func(item RSSItem) error {
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	channel := feed.Channel
+	if channel.Title != "Notes & more" || channel.Link != "https://notes.example.com/" || channel.Description != "A blog" || channel.Language != "en" {
+		t.Errorf("channel = %q %q %q %q", channel.Title, channel.Link, channel.Description, channel.Language)
+	}
+	if len(channel.Item) != 0 {
+		t.Errorf("the items went to each, the feed shouldn't keep them: %d", len(channel.Item))
+	}
+	// the Atom parts of the channel are what backfill and the images come from
+	if next := feed.NextPage("https://notes.example.com/feed.xml"); next != "https://notes.example.com/feed/2023.xml" {
+		t.Errorf("NextPage = %q", next)
+	}
+	if image := feed.ImageURL(); image != "https://notes.example.com/logo.png" {
+		t.Errorf("ImageURL = %q", image)
+	}
+
+	if len(items) != 3 {
+		t.Fatalf("got %d items, want 3", len(items))
+	}
+	if items[0].Title != "First & best" || items[0].Description != "<p>one</p>" || items[2].Link != "https://notes.example.com/3" {
+		t.Errorf("items = %+v", items)
+	}
+}
+
+func TestDecodeFeedStops(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxItems int
+		failOn   string
+		want     int
+	}{
+		{"item cap", 2, "", 2},
+		{"error from each", 0, "Second", 2},
+	}
+	for _, tt := range tests {
+		errStop := errors.New("stop")
+		calls := 0
+		_, err := DecodeFeed(strings.NewReader(streamFeed), tt.maxItems, func(item RSSItem) error {
+			calls++
+			if item.Title == tt.failOn {
+				return errStop
+			}
+			return nil
+		})
+		if tt.failOn == "" && err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		if tt.failOn != "" && !errors.Is(err, errStop) {
+			t.Errorf("%s: err = %v, want the error from each", tt.name, err)
+		}
+		if calls != tt.want {
+			t.Errorf("%s: each called %d times, want %d", tt.name, calls, tt.want)
+		}
+	}
+
+	if _, err := DecodeFeed(strings.NewReader(""), 0, func(RSSItem) error { return nil }); err == nil {
+		t.Error("an empty document should fail")
+	}
+	if _, err := DecodeFeed(strings.NewReader("<rss><channel><item><title>cut"), 0, func(RSSItem) error { return nil }); err == nil {
+		t.Error("a truncated document should fail")
+	}
+}
+
+func TestStreamFeed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/feed.xml" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(streamFeed))
+	}))
+	defer server.Close()
+
+	count := 0
+	feed, err := StreamFeed(context.Background(), server.URL+"/feed.xml", FetchOptions{}, 0, func(RSSItem) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 || feed.Channel.Title != "Notes & more" {
+		t.Errorf("got %d items from %q", count, feed.Channel.Title)
+	}
+
+	// a missing page comes back as a StatusError, so backfill can tell the end of an archive from a failure
+	_, err = StreamFeed(context.Background(), server.URL+"/page/9", FetchOptions{}, 0, func(RSSItem) error { return nil })
+	var status *StatusError
+	if !errors.As(err, &status) || status.StatusCode != http.StatusNotFound {
+		t.Errorf("err = %v, want a 404 StatusError", err)
+	}
+}
+
+func TestStreamFeedWholeResponses(t *testing.T) {
+	// bigger than MaxBodySize, which only applies to fetches that aren't feeds
+	padding := strings.Repeat("x", MaxBodySize)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<rss version="2.0"><channel><title>Big</title>
+<item><title>Huge</title><description>` + padding + `</description></item>
+<item><title>After</title></item></channel></rss>`))
+	}))
+	defer server.Close()
+
+	// the recorder and the replayer aren't Openers, StreamFeed reads their whole response
+	dir := t.TempDir()
+	defer SetFetcher(HTTPFetcher{})
+	for _, f := range []Fetcher{&Recorder{Dir: dir, Next: HTTPFetcher{}}, &Replayer{Dir: dir}} {
+		if _, ok := f.(Opener); ok {
+			t.Fatalf("%T is an Opener, this test wants one that isn't", f)
+		}
+		SetFetcher(f)
+		count := 0
+		feed, err := StreamFeed(context.Background(), server.URL, FetchOptions{}, 0, func(RSSItem) error {
+			count++
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("%T: %v", f, err)
+		}
+		if count != 2 || feed.Channel.Title != "Big" {
+			t.Errorf("%T: got %d items from %q", f, count, feed.Channel.Title)
+		}
+		server.Close()
+	}
+
+	if _, err := FetchPage(context.Background(), server.URL); err == nil {
+		t.Error("a page fetch of the same response should still hit MaxBodySize")
+	}
+}
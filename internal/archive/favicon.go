@@ -0,0 +1,91 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	rss "github.com/azhagan2/blog_aggregator/internal/RSS"
+)
+
+// maxFaviconSize is as big as an icon gets, anything larger is a photo someone linked by mistake
+const maxFaviconSize = 256 << 10
+
+// ErrNoFavicon means the site has no icon we could use
+var ErrNoFavicon = errors.New("no favicon found")
+
+/* SaveFavicon finds the icon of the site at siteURL and saves it in the store, returning its full path.
+The icons the homepage links (<link rel="icon">, apple-touch-icon) are tried first, then /favicon.ico. */
+
+func (s *Store) SaveFavicon(ctx context.Context, siteURL string) (string, error) {
+	base, err := url.Parse(siteURL)
+	if err != nil || base.Host == "" {
+		return "", fmt.Errorf("invalid site url %q", siteURL)
+	}
+
+	var candidates []string
+	// a homepage that doesn't load can still have a /favicon.ico
+	if data, err := rss.FetchPage(ctx, base.String()); err == nil {
+		candidates = iconLinks(data, base)
+	}
+	candidates = append(candidates, base.ResolveReference(&url.URL{Path: "/favicon.ico"}).String())
+
+	for _, candidate := range candidates {
+		data, err := rss.FetchPage(ctx, candidate)
+		if err != nil || len(data) == 0 || len(data) > maxFaviconSize || !isImage(data, candidate) {
+			continue
+		}
+		u, _ := url.Parse(candidate)
+		rel, err := s.Put(data, extension(u, data))
+		if err != nil {
+			return "", err
+		}
+		return s.Path(rel), nil
+	}
+	return "", ErrNoFavicon
+}
+
+// iconLinks returns the icons a page links in its <head>, absolute, in the order they appear
+func iconLinks(data []byte, base *url.URL) []string {
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+
+	var links []string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Link {
+			rel := strings.Fields(strings.ToLower(getAttr(n, "rel")))
+			for _, r := range rel {
+				if r != "icon" && r != "apple-touch-icon" {
+					continue
+				}
+				if ref, err := base.Parse(strings.TrimSpace(getAttr(n, "href"))); err == nil && getAttr(n, "href") != "" {
+					links = append(links, ref.String())
+				}
+				break
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return links
+}
+
+// isImage checks the bytes are an image (or an svg, which sniffs as text), a 200 with an error page is common
+func isImage(data []byte, iconURL string) bool {
+	if strings.HasPrefix(http.DetectContentType(data), "image/") {
+		return true
+	}
+	return strings.HasSuffix(strings.ToLower(iconURL), ".svg") && bytes.Contains(data, []byte("<svg"))
+}
@@ -0,0 +1,221 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	rss "github.com/azhagan2/blog_aggregator/internal/RSS"
+)
+
+var (
+	cssURL  = regexp.MustCompile(`url\(\s*['"]?([^'")]+?)['"]?\s*\)`)
+	safeExt = regexp.MustCompile(`^\.[A-Za-z0-9]{1,6}$`)
+)
+
+// page holds what we need while archiving one page, resources maps an absolute URL to where we stored it
+type page struct {
+	ctx       context.Context
+	store     *Store
+	base      *url.URL
+	resources map[string]string
+}
+
+/* SavePage downloads pageURL together with its images, stylesheets and icons, rewrites the page so it
+points at the local copies, and saves everything in the store. Scripts are dropped, an offline copy
+doesn't need them and they'd only try to reach the network. It returns the full path of the saved
+HTML file, which can be opened straight in a browser.
+
+A resource that fails to download keeps its original URL, a missing logo shouldn't cost us the article. */
+
+func (s *Store) SavePage(ctx context.Context, pageURL string) (string, error) {
+	data, err := rss.FetchPage(ctx, pageURL)
+	if err != nil {
+		return "", err
+	}
+
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid page url: %w", err)
+	}
+
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("error parsing the page html: %w", err)
+	}
+
+	p := &page{ctx: ctx, store: s, base: base, resources: map[string]string{}}
+	p.rewrite(doc)
+
+	var out bytes.Buffer
+	if err := html.Render(&out, doc); err != nil {
+		return "", fmt.Errorf("error rendering the archived page: %w", err)
+	}
+
+	rel, err := s.Put(out.Bytes(), ".html")
+	if err != nil {
+		return "", err
+	}
+	return s.Path(rel), nil
+}
+
+func (p *page) rewrite(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.ElementNode {
+			switch c.DataAtom {
+			case atom.Script, atom.Noscript:
+				n.RemoveChild(c)
+				c = next
+				continue
+			case atom.Base:
+				// <base href> changes what relative URLs mean, use it and then drop it so it doesn't break our local paths
+				if href := getAttr(c, "href"); href != "" {
+					if ref, err := p.base.Parse(href); err == nil {
+						p.base = ref
+					}
+				}
+				n.RemoveChild(c)
+				c = next
+				continue
+			}
+		}
+		p.rewriteNode(c)
+		p.rewrite(c)
+		c = next
+	}
+}
+
+func (p *page) rewriteNode(n *html.Node) {
+	if n.Type != html.ElementNode {
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Img:
+		// srcset lists several sizes, we only keep the one in src
+		removeAttr(n, "srcset")
+		if src := getAttr(n, "src"); src != "" {
+			setAttr(n, "src", p.local(src, p.base, false))
+		}
+	case atom.Link:
+		rel := strings.ToLower(getAttr(n, "rel"))
+		href := getAttr(n, "href")
+		if href == "" {
+			break
+		}
+		if strings.Contains(rel, "stylesheet") {
+			setAttr(n, "href", p.local(href, p.base, true))
+		} else if strings.Contains(rel, "icon") {
+			setAttr(n, "href", p.local(href, p.base, false))
+		}
+	case atom.Style:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.TextNode {
+				c.Data = p.rewriteCSS(c.Data, p.base)
+			}
+		}
+	}
+
+	if style := getAttr(n, "style"); style != "" {
+		setAttr(n, "style", p.rewriteCSS(style, p.base))
+	}
+}
+
+// rewriteCSS stores everything a stylesheet points at with url(...) (fonts, background images) and rewrites it
+func (p *page) rewriteCSS(css string, base *url.URL) string {
+	return cssURL.ReplaceAllStringFunc(css, func(match string) string {
+		ref := strings.TrimSpace(cssURL.FindStringSubmatch(match)[1])
+		if strings.HasPrefix(ref, "data:") || strings.HasPrefix(ref, "#") {
+			return match
+		}
+		return `url("` + p.local(ref, base, false) + `")`
+	})
+}
+
+/* local downloads one resource and returns the path the archived page should use instead. Every file in
+the store sits exactly one directory deep, so from any stored file another one is always at ../xx/hash.ext */
+
+func (p *page) local(ref string, base *url.URL, css bool) string {
+	abs, err := base.Parse(strings.TrimSpace(ref))
+	if err != nil || (abs.Scheme != "http" && abs.Scheme != "https") {
+		return ref
+	}
+	abs.Fragment = ""
+
+	if rel, ok := p.resources[abs.String()]; ok {
+		return rel
+	}
+
+	data, err := rss.FetchPage(p.ctx, abs.String())
+	if err != nil {
+		return ref
+	}
+
+	ext := extension(abs, data)
+	if css {
+		// urls inside a stylesheet are relative to the stylesheet, not the page
+		data = []byte(p.rewriteCSS(string(data), abs))
+		ext = ".css"
+	}
+
+	rel, err := p.store.Put(data, ext)
+	if err != nil {
+		return ref
+	}
+
+	local := "../" + filepath.ToSlash(rel)
+	p.resources[abs.String()] = local
+	return local
+}
+
+// extension keeps a plain file extension from the URL, or guesses one from the content
+func extension(u *url.URL, data []byte) string {
+	if ext := path.Ext(u.Path); safeExt.MatchString(ext) {
+		return strings.ToLower(ext)
+	}
+	contentType := http.DetectContentType(data)
+	if exts, err := mime.ExtensionsByType(contentType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
+
+func getAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func setAttr(n *html.Node, key, val string) {
+	for i := range n.Attr {
+		if n.Attr[i].Key == key {
+			n.Attr[i].Val = val
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+}
+
+func removeAttr(n *html.Node, key string) {
+	attrs := n.Attr[:0]
+	for _, a := range n.Attr {
+		if a.Key != key {
+			attrs = append(attrs, a)
+		}
+	}
+	n.Attr = attrs
+}
@@ -0,0 +1,124 @@
+package archive
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSavePage(t *testing.T) {
+	logo := []byte("\x89PNG\r\n\x1a\n logo")
+	background := []byte("\x89PNG\r\n\x1a\n background")
+	files := map[string]struct {
+		contentType string
+		body        string
+	}{
+		"/2024/post": {"text/html", `<html><head>
+<link rel="stylesheet" href="/css/site.css">
+<script src="/tracker.js"></script>
+</head><body>
+<div style="background: url('/img/bg.png')"><img src="../img/logo.png" srcset="/img/logo-2x.png 2x"></div>
+<a href="/about">About</a>
+</body></html>`},
+		"/css/site.css": {"text/css", `body { background: url("../img/bg.png") } .x { background: url(data:image/png;base64,AAAA) }`},
+		"/img/logo.png": {"image/png", string(logo)},
+		"/img/bg.png":   {"image/png", string(background)},
+	}
+
+	var mu sync.Mutex
+	fetched := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetched[r.URL.Path]++
+		mu.Unlock()
+		f, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", f.contentType)
+		w.Write([]byte(f.body))
+	}))
+	defer server.Close()
+
+	store := NewStore(t.TempDir())
+	saved, err := store.SavePage(context.Background(), server.URL+"/2024/post")
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, err := os.ReadFile(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logoPath := "../" + hashPath(logo, ".png")
+	bgPath := "../" + hashPath(background, ".png")
+	for _, want := range []string{`src="` + logoPath + `"`, `url(&#34;` + bgPath + `&#34;)`, `href="/about"`} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("archived page is missing %s:\n%s", want, page)
+		}
+	}
+	for _, gone := range []string{"tracker.js", "srcset", "/css/site.css", "/img/logo.png"} {
+		if strings.Contains(string(page), gone) {
+			t.Errorf("archived page still has %s:\n%s", gone, page)
+		}
+	}
+
+	// the stylesheet is stored rewritten, pointing at the same background file as the page
+	css := regexp.MustCompile(`href="\.\./([^"]+\.css)"`).FindSubmatch(page)
+	if css == nil {
+		t.Fatalf("no local stylesheet in:\n%s", page)
+	}
+	sheet, err := os.ReadFile(store.Path(string(css[1])))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(sheet), `url("`+bgPath+`")`) || !strings.Contains(string(sheet), "data:image/png") {
+		t.Errorf("stylesheet not rewritten:\n%s", sheet)
+	}
+	if fetched["/img/bg.png"] != 1 {
+		t.Errorf("the background was downloaded %d times, want once", fetched["/img/bg.png"])
+	}
+
+	// every stored file is named after the hash of what's in it
+	err = filepath.WalkDir(store.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(store.Dir, path)
+		if want := hashPath(data, filepath.Ext(path)); rel != want {
+			t.Errorf("%s is stored as %s, want %s", path, rel, want)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := store.SavePage(context.Background(), server.URL+"/2024/post")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != saved {
+		t.Errorf("saving the page again gave %s, want %s", again, saved)
+	}
+}
+
+func hashPath(data []byte, ext string) string {
+	sum := sha256.Sum256(data)
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(name[:2], name+ext)
+}
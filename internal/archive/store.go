@@ -0,0 +1,64 @@
+package archive
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+/* Store is a content-addressed directory: every file is named after the sha256 of its bytes, and
+sharded by the first two hex characters (ab/abcdef...png) so no single directory gets huge. Saving the
+same image or stylesheet twice (a blog's logo on every page) just points at the file that's already there. */
+
+type Store struct {
+	Dir string
+}
+
+func NewStore(dir string) *Store {
+	return &Store{Dir: dir}
+}
+
+// Put saves data under its hash and returns the path relative to the store directory
+
+func (s *Store) Put(data []byte, ext string) (string, error) {
+	sum := sha256.Sum256(data)
+	name := hex.EncodeToString(sum[:])
+	rel := filepath.Join(name[:2], name+ext)
+	full := filepath.Join(s.Dir, rel)
+
+	if _, err := os.Stat(full); err == nil {
+		return rel, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		return "", fmt.Errorf("error creating archive directory: %w", err)
+	}
+
+	// write to a temp file and rename, so a crash never leaves a half-written file under a valid hash
+	tmp, err := os.CreateTemp(filepath.Dir(full), ".tmp-*")
+	if err != nil {
+		return "", fmt.Errorf("error creating archive file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("error writing archive file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("error writing archive file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), full); err != nil {
+		return "", fmt.Errorf("error saving archive file: %w", err)
+	}
+
+	return rel, nil
+}
+
+// Path turns a path returned by Put into a full path on disk
+
+func (s *Store) Path(rel string) string {
+	return filepath.Join(s.Dir, rel)
+}
@@ -0,0 +1,208 @@
+package command
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/azhagan2/blog_aggregator/internal/database"
+	"github.com/azhagan2/blog_aggregator/internal/render"
+	"github.com/azhagan2/blog_aggregator/internal/state"
+)
+
+/* HandlerSave keeps a post for later, whether it's read or not:
+gator save {post_url} [--note "text"] [--tag go,later]
+Saving it again updates the note (when one is given) and adds the tags. The bookmark keeps its own copy
+of the post, so it stays after the feed is unfollowed or deleted. */
+
+func HandlerSave(s *state.State, cmd Clicommand, user database.User) error {
+
+	if len(cmd.Argument) == 0 {
+		return fmt.Errorf("the handler expects a post url, and optionally --note {text} and --tag {tags}")
+	}
+
+	postURL := cmd.Argument[0]
+	var note sql.NullString
+	var tags []string
+	for i := 1; i < len(cmd.Argument); i++ {
+		switch cmd.Argument[i] {
+		case "--note", "--tag":
+			if i+1 >= len(cmd.Argument) {
+				return fmt.Errorf("%s needs a value", cmd.Argument[i])
+			}
+			if cmd.Argument[i] == "--note" {
+				note = toNullString(strings.TrimSpace(cmd.Argument[i+1]))
+			} else {
+				tags = append(tags, parseTags(cmd.Argument[i+1])...)
+			}
+			i++
+		default:
+			return fmt.Errorf("unexpected %q, the note goes after --note", cmd.Argument[i])
+		}
+	}
+
+	ctx := context.Background()
+	params, err := bookmarkCopy(s, user, postURL)
+	if err != nil {
+		return err
+	}
+	params.Note = note
+
+	var bookmark database.Bookmark
+	err = s.Db.InTx(ctx, func(q database.Store) error {
+		var err error
+		bookmark, err = q.UpsertBookmark(ctx, params)
+		if err != nil {
+			return fmt.Errorf("error saving the post %w", err)
+		}
+		for _, tag := range tags {
+			err := q.AddBookmarkTag(ctx, database.AddBookmarkTagParams{BookmarkID: bookmark.ID, Tag: tag})
+			if err != nil {
+				return fmt.Errorf("error tagging the post %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Saved :", bookmark.Title)
+	if bookmark.Note.Valid {
+		fmt.Println("Note :", bookmark.Note.String)
+	}
+	return nil
+}
+
+/* bookmarkCopy is what a bookmark of postURL stores: the post as it is now (the full text when we have it),
+or when the post is gone already, the copy an earlier save kept, so a saved post can still be tagged. */
+
+func bookmarkCopy(s *state.State, user database.User, postURL string) (database.UpsertBookmarkParams, error) {
+	ctx := context.Background()
+	params := database.UpsertBookmarkParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: user.ID, Url: postURL}
+
+	post, err := s.Db.GetPostByURL(ctx, postURL)
+	if errors.Is(err, sql.ErrNoRows) {
+		saved, err := s.Db.GetBookmarks(ctx, database.GetBookmarksParams{UserID: user.ID})
+		if err != nil {
+			return params, fmt.Errorf("error getting the saved posts %w", err)
+		}
+		for _, b := range saved {
+			if b.Url == postURL {
+				params.Title, params.Description, params.PublishedAt, params.FeedName = b.Title, b.Description, b.PublishedAt, b.FeedName
+				return params, nil
+			}
+		}
+		return params, fmt.Errorf("there is no post at %s, gator browse shows the post urls", postURL)
+	}
+	if err != nil {
+		return params, fmt.Errorf("error getting the post %w", err)
+	}
+
+	params.PostID = uuid.NullUUID{UUID: post.ID, Valid: true}
+	params.Title = post.Title
+	params.Description = post.Description
+	if post.Content.Valid {
+		params.Description = post.Content.String
+	}
+	params.PublishedAt = post.PublishedAt
+	if post.FeedID.Valid {
+		if feed, err := s.Db.GetFeedByID(ctx, post.FeedID.UUID); err == nil {
+			params.FeedName = toNullString(feed.Name)
+		}
+	}
+	return params, nil
+}
+
+// parseTags splits "Go, #later" into go and later
+
+func parseTags(list string) []string {
+	var tags []string
+	for _, tag := range strings.Split(list, ",") {
+		tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// HandlerSaved lists the saved posts, newest saved first: gator saved [--tag {tag}]
+
+func HandlerSaved(s *state.State, cmd Clicommand, user database.User) error {
+
+	params := database.GetBookmarksParams{UserID: user.ID}
+	if len(cmd.Argument) > 0 {
+		if cmd.Argument[0] != "--tag" || len(cmd.Argument) < 2 {
+			return fmt.Errorf("the handler expects no arguments, or --tag {tag}")
+		}
+		if tags := parseTags(cmd.Argument[1]); len(tags) > 0 {
+			params.Tag = sql.NullString{String: tags[0], Valid: true}
+		}
+	}
+
+	ctx := context.Background()
+	saved, err := s.Db.GetBookmarks(ctx, params)
+	if err != nil {
+		return fmt.Errorf("error getting the saved posts %w", err)
+	}
+	tagRows, err := s.Db.GetBookmarkTags(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("error getting the tags %w", err)
+	}
+	tags := map[uuid.UUID][]string{}
+	for _, t := range tagRows {
+		tags[t.BookmarkID] = append(tags[t.BookmarkID], t.Tag)
+	}
+
+	fmt.Printf("Found %d saved posts\n", len(saved))
+
+	width := render.TerminalWidth()
+
+	for _, b := range saved {
+		fmt.Println()
+		fmt.Println("Post Name :", b.Title)
+		if b.FeedName.Valid {
+			fmt.Println("Feed Name :", b.FeedName.String)
+		}
+		fmt.Println("Feed URL :", b.Url)
+		if b.PublishedAt.Valid {
+			fmt.Println("Published :", b.PublishedAt.Time.Format("2006-01-02"))
+		}
+		fmt.Println("Saved :", b.CreatedAt.Format("2006-01-02"))
+		if b.Note.Valid {
+			fmt.Println("Note :", b.Note.String)
+		}
+		if len(tags[b.ID]) > 0 {
+			fmt.Println("Tags :", strings.Join(tags[b.ID], ", "))
+		}
+		fmt.Println(render.HTMLToText(b.Description, width))
+	}
+
+	return nil
+}
+
+// HandlerUnsave forgets a saved post, its note and tags: gator unsave {post_url}
+
+func HandlerUnsave(s *state.State, cmd Clicommand, user database.User) error {
+
+	if len(cmd.Argument) == 0 {
+		return fmt.Errorf("the handler expects a single argument, the post url")
+	}
+
+	n, err := s.Db.DeleteBookmark(context.Background(), database.DeleteBookmarkParams{UserID: user.ID, Url: cmd.Argument[0]})
+	if err != nil {
+		return fmt.Errorf("error removing the saved post %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s isn't saved", cmd.Argument[0])
+	}
+
+	fmt.Println("Removed from saved :", cmd.Argument[0])
+	return nil
+}
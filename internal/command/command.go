@@ -1,17 +1,26 @@
 package command
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
-	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
+
 	rss "github.com/azhagan2/blog_aggregator/internal/RSS"
+	"github.com/azhagan2/blog_aggregator/internal/archive"
 	"github.com/azhagan2/blog_aggregator/internal/database"
+	"github.com/azhagan2/blog_aggregator/internal/extract"
+	"github.com/azhagan2/blog_aggregator/internal/render"
+	"github.com/azhagan2/blog_aggregator/internal/resolve"
 	"github.com/azhagan2/blog_aggregator/internal/state"
 )
 
@@ -128,7 +137,7 @@ func HandlerRegister(s *state.State, cmd Clicommand) error {
 		return fmt.Errorf("the handler expects a single argument, the username")
 	}
 
-	user, err := s.Db.CreateUser(context.Background(), database.CreateUserParams{ID: int32(rand.Intn(1000000)),
+	user, err := s.Db.CreateUser(context.Background(), database.CreateUserParams{ID: uuid.New(),
 		CreatedAt: time.Now(), UpdatedAt: time.Now(), Name: cmd.Argument[0]})
 	if err != nil {
 		return err
@@ -181,6 +190,10 @@ func HandlerAgg(s *state.State, cmd Clicommand) error {
 		return fmt.Errorf("error in parsing duration and converting to actual time %w", err)
 	}
 
+	if webSubEnabled(s) {
+		go serveWebSub(s)
+	}
+
 	// fmt.Println("Parsed time: ", timeBetweenRequests)
 	ticker := time.NewTicker(timeBetweenRequests)
 	for ; ; <-ticker.C {
@@ -216,18 +229,23 @@ func HandlerAddfeed(s *state.State, cmd Clicommand, user database.User) error {
 	// 	return fmt.Errorf("unregistered user :%w", err)
 	// }
 
-	current_userid := sql.NullInt32{Int32: user.ID, Valid: true}
+	current_userid := uuid.NullUUID{UUID: user.ID, Valid: true}
 
-	feed, err := s.Db.CreateFeed(context.Background(), database.CreateFeedParams{ID: int32(rand.Intn(1000000)),
-		CreatedAt: time.Now(), UpdatedAt: time.Now(), Name: cmd.Argument[0], Url: cmd.Argument[1], UserID: current_userid})
+	feedURL, err := feedURLFor(cmd.Argument[1])
+	if err != nil {
+		return err
+	}
+
+	feed, err := s.Db.CreateFeed(context.Background(), database.CreateFeedParams{ID: uuid.New(),
+		CreatedAt: time.Now(), UpdatedAt: time.Now(), Name: cmd.Argument[0], Url: feedURL, UserID: current_userid})
 	if err != nil {
 		return fmt.Errorf("couldn't create the feed: %w", err)
 	}
-	fmt.Println(feed.Url)
+	fmt.Println(rss.RedactURL(feed.Url))
 
-	current_feedid := sql.NullInt32{Int32: feed.ID, Valid: true}
+	current_feedid := uuid.NullUUID{UUID: feed.ID, Valid: true}
 
-	feed_follows, err := s.Db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{ID: int32(rand.Intn(1000000)),
+	feed_follows, err := s.Db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{ID: uuid.New(),
 		CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: current_userid, FeedID: current_feedid})
 	if err != nil {
 		return fmt.Errorf("error in following feed %w", err)
@@ -246,9 +264,19 @@ func HandlerFeeds(s *state.State, cmd Clicommand) error {
 
 	for i := range feeds {
 		fmt.Println(feeds[i].Name)
-		fmt.Println(feeds[i].Url)
+		fmt.Println(rss.RedactURL(feeds[i].Url))
+		printFeedMetadata(feeds[i].Name, feeds[i].Title, feeds[i].SiteUrl, feeds[i].Description)
+		if feeds[i].Language.String != "" {
+			fmt.Println("Language :", feeds[i].Language.String)
+		}
+		if feeds[i].ImageUrl.String != "" {
+			fmt.Println("Image :", feeds[i].ImageUrl.String)
+		}
+		if feeds[i].FaviconPath.String != "" {
+			fmt.Println("Favicon :", feeds[i].FaviconPath.String)
+		}
 
-		author, err := s.Db.GetUserById(context.Background(), feeds[i].UserID.Int32)
+		author, err := s.Db.GetUserById(context.Background(), feeds[i].UserID.UUID)
 		if err != nil {
 			return fmt.Errorf("error fetching authod by user_id %w", err)
 		} else {
@@ -258,44 +286,87 @@ func HandlerFeeds(s *state.State, cmd Clicommand) error {
 	return nil
 }
 
+// HandlerFollow follows a feed that's already added: gator follow {url} [--folder {folder}]
+
 func HandlerFollow(s *state.State, cmd Clicommand, user database.User) error {
 
-	user, err := s.Db.GetUser(context.Background(), s.Cfg.CurrentUserName)
+	folder, args, err := folderFlag(cmd.Argument)
+	if err != nil {
+		return err
+	}
+	cmd.Argument = args
+
+	if len(cmd.Argument) == 0 {
+		return fmt.Errorf("the handler expects a single argument, the feed url")
+	}
+
+	user, err = s.Db.GetUser(context.Background(), s.Cfg.CurrentUserName)
 	if err != nil {
 		return fmt.Errorf("error getting user_id for feed_follow %w", err)
 	}
 
-	current_userid := sql.NullInt32{Int32: user.ID, Valid: true}
+	current_userid := uuid.NullUUID{UUID: user.ID, Valid: true}
 
-	feed, err := s.Db.GetFeed_ByURL(context.Background(), cmd.Argument[0])
+	feed, _, err := feedFor(s, cmd.Argument[0])
 	if err != nil {
 		return fmt.Errorf("error getting feed name %w", err)
 	}
 
-	current_feedid := sql.NullInt32{Int32: feed.ID, Valid: true}
+	current_feedid := uuid.NullUUID{UUID: feed.ID, Valid: true}
 
-	feed_follows, err := s.Db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{ID: int32(rand.Intn(1000000)),
+	feed_follows, err := s.Db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{ID: uuid.New(),
 		CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: current_userid, FeedID: current_feedid})
 	if err != nil {
 		return fmt.Errorf("error in following feed %w", err)
 	}
 
+	if folder.Valid {
+		if err := setFolder(s.Db, user, feed, folder); err != nil {
+			return err
+		}
+	}
+
 	fmt.Println(feed_follows)
 	fmt.Println("Feed Followed !")
 	fmt.Println("Feed Name :", feed.Name)
 	fmt.Println("Followed by :", user.Name)
+	if folder.Valid {
+		fmt.Println("Folder :", folder.String)
+	}
 
 	return nil
 }
 
+/* HandlerFollowing lists the followed feeds, the ones in no folder first and then folder by folder with
+the folder's unread count: gator following [--folder {folder}] */
+
 func HandlerFollowing(s *state.State, cmd Clicommand, user database.User) error {
-	feeds, err := s.Db.GetFeedFollowsForUser(context.Background(), s.Cfg.CurrentUserName)
+	folder, _, err := folderFlag(cmd.Argument)
+	if err != nil {
+		return err
+	}
+
+	feeds, err := s.Db.GetFeedFollowsForUser(context.Background(), database.GetFeedFollowsForUserParams{
+		Name:   s.Cfg.CurrentUserName,
+		Folder: folder,
+	})
 	if err != nil {
 		return fmt.Errorf("error getting the feeds for the user %w", err)
 	}
 
+	unread := map[string]int64{}
 	for i := range feeds {
-		fmt.Println(feeds[i])
+		unread[feeds[i].Folder.String] += feeds[i].Unread
+	}
+
+	for i := range feeds {
+		if feeds[i].Folder.Valid && (i == 0 || feeds[i].Folder != feeds[i-1].Folder) {
+			fmt.Println()
+			fmt.Printf("Folder : %s (%d unread)\n", feeds[i].Folder.String, unread[feeds[i].Folder.String])
+		}
+		fmt.Println(feeds[i].Name)
+		printFeedMetadata(feeds[i].Name, feeds[i].Title, feeds[i].SiteUrl, feeds[i].Description)
+		fmt.Println("Unread :", feeds[i].Unread)
 	}
 	fmt.Println("user:", user.Name)
 
@@ -304,14 +375,18 @@ func HandlerFollowing(s *state.State, cmd Clicommand, user database.User) error
 
 func HandlerUnfollow(s *state.State, cmd Clicommand, user database.User) error {
 
-	feed, err := s.Db.GetFeed_ByURL(context.Background(), cmd.Argument[0])
+	if len(cmd.Argument) == 0 {
+		return fmt.Errorf("the handler expects a single argument, the feed url")
+	}
+
+	feed, _, err := feedFor(s, cmd.Argument[0])
 	if err != nil {
 		return fmt.Errorf("error getting feed name %w", err)
 	}
 
-	feed_id := sql.NullInt32{Int32: feed.ID, Valid: true}
+	feed_id := uuid.NullUUID{UUID: feed.ID, Valid: true}
 
-	user_id := sql.NullInt32{Int32: user.ID, Valid: true}
+	user_id := uuid.NullUUID{UUID: user.ID, Valid: true}
 
 	err = s.Db.Delete_Feed_Follow(context.Background(), database.Delete_Feed_FollowParams{UserID: user_id, FeedID: feed_id})
 	if err != nil {
@@ -323,6 +398,63 @@ func HandlerUnfollow(s *state.State, cmd Clicommand, user database.User) error {
 	return nil
 }
 
+// fileURLFor makes file:// urls absolute, so file://feeds/x.xml still works when agg runs from another directory
+
+func fileURLFor(raw string) (string, error) {
+	path, err := filepath.Abs(strings.TrimPrefix(raw, "file://"))
+	if err != nil {
+		return "", fmt.Errorf("invalid file path %w", err)
+	}
+	return "file://" + filepath.ToSlash(path), nil
+}
+
+/* feedFor finds a feed gator has by what the user pasted, and the URL it looked for. The URL as it is
+(made absolute for file://) is tried first, so a stored feed never needs the network; only a miss is resolved
+like addfeed does it, which for mastodon.social/@user asks the server. sql.ErrNoRows when there's no such feed. */
+
+func feedFor(s *state.State, raw string) (database.Feed, string, error) {
+	ctx := context.Background()
+	stored := strings.TrimSpace(raw)
+	if strings.HasPrefix(stored, "file://") {
+		var err error
+		if stored, err = fileURLFor(stored); err != nil {
+			return database.Feed{}, stored, err
+		}
+	}
+	feed, err := s.Db.GetFeed_ByURL(ctx, stored)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return feed, stored, err
+	}
+
+	feedURL, err := feedURLFor(raw)
+	if err != nil {
+		return database.Feed{}, raw, err
+	}
+	if feedURL == stored {
+		return database.Feed{}, feedURL, sql.ErrNoRows
+	}
+	feed, err = s.Db.GetFeed_ByURL(ctx, feedURL)
+	return feed, feedURL, err
+}
+
+/* feedURLFor turns what the user pasted into the URL we store, so youtube.com/@channel, reddit.com/r/golang,
+mastodon.social/@user or github.com/org/repo become their real feed URLs. Normal feed URLs come back as they are. */
+
+func feedURLFor(raw string) (string, error) {
+	if strings.HasPrefix(raw, "file://") {
+		return fileURLFor(raw)
+	}
+
+	feedURL, err := resolve.FeedURL(context.Background(), raw)
+	if err != nil {
+		return "", err
+	}
+	if feedURL != raw {
+		fmt.Printf("Using feed %s for %s\n", feedURL, raw)
+	}
+	return feedURL, nil
+}
+
 func scrapeFeeds(s *state.State) error {
 
 	// fmt.Println("entered the scrape funcion")
@@ -333,58 +465,277 @@ func scrapeFeeds(s *state.State) error {
 
 	fmt.Println("Feed Name :", feed.Name)
 
-	// fmt.Println("searching the feed")
-	err = s.Db.Mark_Feed_Fetched(context.Background(), feed.ID)
+	if feed.Kind == "stdin" || pushedByHub(s, feed) {
+		if feed.Kind == "stdin" {
+			fmt.Println("Feed is filled by gator ingest, nothing to fetch")
+		} else {
+			fmt.Println("Feed is pushed by its WebSub hub, skipping the poll")
+		}
+		return markFeedFetched(s.Db, feed)
+	}
+
+	fmt.Println("Creating Posts !")
+
+	/* The feed is decoded as it arrives, and every postBatchSize items are stored in a transaction of their
+	own while the download goes on: a slow server never holds the database (SQLite has a single connection,
+	the WebSub handler needs it too) or the feed's row lock, and only one batch sits in memory. The last
+	batch, marking the feed fetched and its metadata go in together at the end. A fetch that dies halfway
+	keeps the batches already stored and nothing else, the next fetch skips them as duplicates. */
+	batch := newPostBatch(s.Db, feed)
+	rss_result, err := fetchFeed(s, feed, batch.add)
 	if err != nil {
-		return fmt.Errorf("error marking last seen feed as fetched %w", err)
+		err = fmt.Errorf("error in fetching from xml %w", err)
 	}
 
-	// fmt.Println("returing the rss feed")
-	rss_result, err := rss.FetchFeed(context.Background(), feed.Url)
+	if err == nil {
+		err = s.Db.InTx(context.Background(), func(q database.Store) error {
+			if err := markFeedFetched(q, feed); err != nil {
+				return err
+			}
+			if err := batch.flush(q); err != nil {
+				return err
+			}
+			return saveFeedMetadata(q, feed, rss_result)
+		})
+	}
 	if err != nil {
-		return fmt.Errorf("error in fetching from xml %w", err)
+		// still move the feed to the back of the queue, or a feed that always fails would be the only one agg ever fetches
+		if markErr := markFeedFetched(s.Db, feed); markErr != nil {
+			fmt.Println(markErr)
+		}
+		return err
 	}
 
 	fmt.Println("Following feed name: ", rss_result.Channel.Title)
 
-	fmt.Println("Creating Posts !")
+	// the rest talks to other servers, so it waits until the posts are committed
+	fetchFullTexts(s, feed, batch.fullText)
 
-	for _, item := range rss_result.Channel.Item {
-		formats := []string{time.RFC1123Z, time.RFC1123, time.RFC822, time.RFC3339, "2006-01-02T15:04:05Z"}
-		var publishedTime time.Time
-		var parseErr error
-		for _, format := range formats {
-			publishedTime, parseErr = time.Parse(format, item.PubDate)
-			if parseErr == nil {
-				break
-			}
-		}
+	if err := saveFeedImages(s, feed, rss_result); err != nil {
+		// just the icon, the posts are already in
+		fmt.Println("Couldn't save the feed image:", err)
+	}
 
-		fmt.Println("Post Title :", item.Title)
-		// fmt.Println("Post Description :", item.Description)
-		fmt.Println()
-		_, err = s.Db.CreatePost(context.Background(), database.CreatePostParams{
-			ID:          int32(rand.Intn(1000000)),
+	if err := subscribeToHub(s, feed, rss_result); err != nil {
+		// polling still works, so a hub that says no is not a reason to drop this run
+		fmt.Println("Couldn't subscribe to the WebSub hub:", err)
+	}
+
+	fmt.Println("Post is posted !")
+	return nil
+}
+
+func markFeedFetched(q database.Store, feed database.Feed) error {
+	err := q.Mark_Feed_Fetched(context.Background(), feed.ID)
+	if err != nil {
+		return fmt.Errorf("error marking last seen feed as fetched %w", err)
+	}
+	return nil
+}
+
+/* saveFeedMetadata keeps what the feed says about itself (title, description, homepage, language) in
+the feeds row, refreshed on every fetch. The name given at addfeed stays the feed's name. */
+
+func saveFeedMetadata(q database.Store, feed database.Feed, fetched *rss.RSSFeed) error {
+	channel := fetched.Channel
+	err := q.SetFeedMetadata(context.Background(), database.SetFeedMetadataParams{
+		ID:          feed.ID,
+		Title:       toNullString(strings.TrimSpace(channel.Title)),
+		Description: toNullString(strings.TrimSpace(channel.Description)),
+		SiteUrl:     toNullString(strings.TrimSpace(channel.Link)),
+		Language:    toNullString(strings.TrimSpace(channel.Language)),
+	})
+	if err != nil {
+		return fmt.Errorf("error saving the feed metadata %w", err)
+	}
+	return nil
+}
+
+/* createPosts stores feed items as posts, skipping the ones we already have, and returns the new ones.
+Polling, WebSub pushes, ingest and backfill all end up here. All of items go in one transaction, with
+one multi-row INSERT per postsPerInsert items. */
+
+func createPosts(q database.Store, feed database.Feed, items []rss.RSSItem) ([]database.Post, error) {
+
+	params := make([]database.CreatePostParams, len(items))
+	for i, item := range items {
+		// a date we can't read leaves published_at empty, the post is still worth keeping
+		publishedTime, _ := rss.ParseDate(item.PubDate)
+
+		params[i] = database.CreatePostParams{
+			ID:          uuid.New(),
 			CreatedAt:   time.Now(),
 			UpdatedAt:   time.Now(),
 			Title:       item.Title,
 			Url:         item.Link,
 			Description: item.Description,
 			PublishedAt: toNullTime(publishedTime),
-			FeedID:      sql.NullInt32{Int32: feed.ID, Valid: true}})
+			FeedID:      uuid.NullUUID{UUID: feed.ID, Valid: true}}
+	}
+
+	var created []database.Post
+	err := q.InTx(context.Background(), func(q database.Store) error {
+		var err error
+		created, err = q.CreatePosts(context.Background(), params)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating posts %w", err)
+	}
+
+	isNew := make(map[uuid.UUID]bool, len(created))
+	for _, post := range created {
+		isNew[post.ID] = true
+	}
+	for i, item := range items {
+		fmt.Println("Post Title :", item.Title)
+		// fmt.Println("Post Description :", item.Description)
+		fmt.Println()
+		if !isNew[params[i].ID] {
+			// the url or the title is already stored
+			fmt.Printf("Post already exists: %s\n", item.Link)
+		}
+	}
+
+	return created, nil
+}
+
+// postBatchSize is how many streamed items are stored with one createPosts
+
+const postBatchSize = 500
+
+/* postBatch stores the items of a streamed feed as they come, a transaction per postBatchSize items.
+What's left when the feed ends is flushed by the caller, in the transaction that finishes the fetch. */
+
+type postBatch struct {
+	q       database.Store
+	feed    database.Feed
+	items   []rss.RSSItem
+	read    int
+	created int
+	// the new posts that still need their full text, only kept for feeds with fulltext on
+	fullText []database.Post
+}
+
+func newPostBatch(q database.Store, feed database.Feed) *postBatch {
+	return &postBatch{q: q, feed: feed}
+}
+
+func (b *postBatch) add(item rss.RSSItem) error {
+	b.items = append(b.items, item)
+	b.read++
+	if len(b.items) >= postBatchSize {
+		return b.flush(b.q)
+	}
+	return nil
+}
+
+// flush stores the items added since the last flush through q
+
+func (b *postBatch) flush(q database.Store) error {
+	if len(b.items) == 0 {
+		return nil
+	}
+	posts, err := createPosts(q, b.feed, b.items)
+	b.items = b.items[:0]
+	if err != nil {
+		return err
+	}
+	b.created += len(posts)
+	if b.feed.FetchFullText {
+		b.fullText = append(b.fullText, posts...)
+	}
+	return nil
+}
+
+// fetchFullTexts fetches the articles of new posts when the feed has fulltext on, outside any transaction
+
+func fetchFullTexts(s *state.State, feed database.Feed, posts []database.Post) {
+	if !feed.FetchFullText {
+		return
+	}
+	for _, post := range posts {
+		// a page we can't extract shouldn't stop the rest of the feed, the description is still there
+		if err := fetchFullText(s, post); err != nil {
+			fmt.Printf("Couldn't fetch full text for %s: %v\n", post.Url, err)
+		}
+	}
+}
+
+/* fetchFeed reads a feed the way its kind says, a normal RSS feed or an html page scraped with CSS selectors,
+and calls each for its items. RSS is streamed and stops at the item cap, the returned feed has no items. */
+
+func fetchFeed(s *state.State, feed database.Feed, each func(rss.RSSItem) error) (*rss.RSSFeed, error) {
+	opts, err := fetchOptions(s, feed)
+	if err != nil {
+		return &rss.RSSFeed{}, err
+	}
+
+	if feed.Kind == "html" {
+		page, err := rss.ScrapeHTML(context.Background(), feed.Url, rss.Selectors{
+			Item:    feed.ItemSelector.String,
+			Title:   feed.TitleSelector.String,
+			Link:    feed.LinkSelector.String,
+			Date:    feed.DateSelector.String,
+			Summary: feed.SummarySelector.String,
+		}, opts)
 		if err != nil {
-			// Check if it's a duplicate URL error
-			if strings.Contains(err.Error(), "UNIQUE constraint") {
-				// Just log and continue if it's a duplicate
-				fmt.Printf("Post already exists: %s\n", item.Link)
-				continue
+			return page, err
+		}
+		for _, item := range page.Channel.Item {
+			if err := each(item); err != nil {
+				return page, err
 			}
-			// For other errors, return them
-			return fmt.Errorf("error creating posts %w", err)
 		}
+		return page, nil
+	}
+	return rss.StreamFeed(context.Background(), feed.Url, opts, maxFeedItems(s), each)
+}
+
+// maxFeedItems is how many items one fetch reads at most, max_feed_items in the config
+
+func maxFeedItems(s *state.State) int {
+	if s.Cfg.MaxFeedItems > 0 {
+		return s.Cfg.MaxFeedItems
+	}
+	return rss.DefaultMaxItems
+}
+
+/* fetchOptions builds the per-feed fetch settings: the decrypted credentials of a private feed, and its
+network overrides from the config. Only a feed added as a local file may read local files, so a
+remote feed can't point its next page (or anything else) at file:///etc/passwd. */
+
+func fetchOptions(s *state.State, feed database.Feed) (rss.FetchOptions, error) {
+	auth, err := feedAuth(s, feed)
+	if err != nil {
+		return rss.FetchOptions{}, err
+	}
+	override := s.Cfg.FeedNetwork[feed.Url]
+	return rss.FetchOptions{Auth: auth, Proxy: override.Proxy, ServerName: override.TLSServerName,
+		AllowFile: strings.HasPrefix(feed.Url, "file://")}, nil
+}
+
+// fetchFullText downloads the page a post links to and stores the extracted article body next to the feed's description
+
+func fetchFullText(s *state.State, post database.Post) error {
+	page, err := rss.FetchPage(context.Background(), post.Url)
+	if err != nil {
+		return err
+	}
+
+	content, err := extract.Article(bytes.NewReader(page), post.Url)
+	if err != nil {
+		return err
+	}
+
+	err = s.Db.SetPostContent(context.Background(), database.SetPostContentParams{
+		ID:      post.ID,
+		Content: sql.NullString{String: content, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("error saving full text %w", err)
 	}
 
-	fmt.Println("Post is posted !")
 	return nil
 }
 
@@ -395,11 +746,43 @@ func toNullTime(t time.Time) sql.NullTime {
 	return sql.NullTime{Time: t, Valid: true}
 }
 
+func toNullString(str string) sql.NullString {
+	return sql.NullString{String: str, Valid: str != ""}
+}
+
+// printFeedMetadata shows what the feed calls itself, the title only when it's not just the name again
+
+func printFeedMetadata(name string, title, siteURL, description sql.NullString) {
+	if title.String != "" && title.String != name {
+		fmt.Println("Title :", title.String)
+	}
+	if siteURL.String != "" {
+		fmt.Println("Homepage :", siteURL.String)
+	}
+	if description.String != "" {
+		fmt.Println("Description :", description.String)
+	}
+}
+
+/* HandlerBrowse shows the newest unread posts of the followed feeds and marks them read, so the next
+browse moves on to the ones after: gator browse [limit] [--all] [--folder {folder}]. --all shows read
+posts too, --folder only the feeds in that folder. */
+
 func HandlerBrowse(s *state.State, cmd Clicommand, user database.User) error {
 
+	folder, args, err := folderFlag(cmd.Argument)
+	if err != nil {
+		return err
+	}
+
 	limit := 2
-	if len(cmd.Argument) > 0 {
-		parsedLimit, err := strconv.Atoi(cmd.Argument[0])
+	all := false
+	for _, arg := range args {
+		if arg == "--all" {
+			all = true
+			continue
+		}
+		parsedLimit, err := strconv.Atoi(arg)
 		if err != nil {
 			return fmt.Errorf("invalid limit %v", err)
 		}
@@ -409,8 +792,10 @@ func HandlerBrowse(s *state.State, cmd Clicommand, user database.User) error {
 	// fmt.Println("came to handlerBrowse")
 
 	posts, err := s.Db.GetPostsForUser(context.Background(), database.GetPostsForUserParams{
-		UserID: sql.NullInt32{Int32: user.ID, Valid: true},
-		Limit:  int32(limit),
+		UserID:     uuid.NullUUID{UUID: user.ID, Valid: true},
+		UnreadOnly: !all,
+		Folder:     folder,
+		Limit:      int32(limit),
 	})
 	if err != nil {
 		return fmt.Errorf("error in fetching user's following feed posts %w", err)
@@ -418,21 +803,328 @@ func HandlerBrowse(s *state.State, cmd Clicommand, user database.User) error {
 
 	// fmt.Println("came to handlerBrowse 2")
 
-	fmt.Printf("Found %d posts\n", len(posts))
+	if all {
+		fmt.Printf("Found %d posts\n", len(posts))
+	} else {
+		fmt.Printf("Found %d unread posts\n", len(posts))
+	}
+
+	width := render.TerminalWidth()
 
 	for i := range posts {
 
 		fmt.Println()
 		fmt.Println("Post Name :", posts[i].Title)
-		fmt.Println("Feed Name :", posts[i].Name)
+		fmt.Println("Feed Name :", posts[i].FeedName)
 		fmt.Println("Feed URL :", posts[i].Url)
-		fmt.Println("Feed Description :", posts[i].Description)
+		if posts[i].Content.Valid {
+			fmt.Println("Feed Content :")
+			fmt.Println(render.HTMLToText(posts[i].Content.String, width))
+		} else {
+			fmt.Println("Feed Description :")
+			fmt.Println(render.HTMLToText(posts[i].Description, width))
+		}
 		fmt.Println("Feed Description :", posts[i].PublishedAt)
+		if posts[i].ArchivePath.Valid {
+			fmt.Println("Archived copy :", posts[i].ArchivePath.String)
+		}
+		if all && posts[i].IsRead {
+			fmt.Println("Read")
+		}
 		fmt.Println()
 
+		if err := markPostRead(s, user, posts[i].ID); err != nil {
+			return err
+		}
 	}
 
 	// fmt.Println("came to handlerBrowse 2")
 
 	return nil
 }
+
+// HandlerFullText turns full article fetching on or off for a feed, e.g. gator fulltext {url} on
+
+func HandlerFullText(s *state.State, cmd Clicommand, user database.User) error {
+
+	if len(cmd.Argument) < 2 {
+		return fmt.Errorf("the handler expects two arguments, the feed url and on/off")
+	}
+
+	var enabled bool
+	switch cmd.Argument[1] {
+	case "on":
+		enabled = true
+	case "off":
+		enabled = false
+	default:
+		return fmt.Errorf("expected on or off, got %q", cmd.Argument[1])
+	}
+
+	feed, err := s.Db.GetFeed_ByURL(context.Background(), cmd.Argument[0])
+	if err != nil {
+		return fmt.Errorf("error getting feed name %w", err)
+	}
+
+	err = s.Db.SetFeedFullText(context.Background(), database.SetFeedFullTextParams{ID: feed.ID, FetchFullText: enabled})
+	if err != nil {
+		return fmt.Errorf("error updating full text setting %w", err)
+	}
+
+	fmt.Printf("Full text for %s: %s\n", feed.Name, cmd.Argument[1])
+
+	return nil
+}
+
+// HandlerArchive saves an offline copy of the posts from followed feeds that aren't archived yet, e.g. gator archive 20
+
+func HandlerArchive(s *state.State, cmd Clicommand, user database.User) error {
+
+	limit := 10
+	if len(cmd.Argument) > 0 {
+		parsedLimit, err := strconv.Atoi(cmd.Argument[0])
+		if err != nil {
+			return fmt.Errorf("invalid limit %v", err)
+		}
+		limit = parsedLimit
+	}
+
+	dir, err := s.Cfg.ArchivePath()
+	if err != nil {
+		return fmt.Errorf("error finding the archive directory %w", err)
+	}
+	store := archive.NewStore(dir)
+
+	posts, err := s.Db.GetPostsToArchive(context.Background(), database.GetPostsToArchiveParams{
+		UserID: uuid.NullUUID{UUID: user.ID, Valid: true},
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		return fmt.Errorf("error fetching posts to archive %w", err)
+	}
+
+	fmt.Printf("Archiving %d posts into %s\n", len(posts), dir)
+
+	for _, post := range posts {
+		location, err := store.SavePage(context.Background(), post.Url)
+		if err != nil {
+			// one dead link shouldn't stop the rest, it'll be tried again next time
+			fmt.Printf("Couldn't archive %s: %v\n", post.Url, err)
+			continue
+		}
+
+		err = s.Db.SetPostArchive(context.Background(), database.SetPostArchiveParams{
+			ID:          post.ID,
+			ArchivePath: sql.NullString{String: location, Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("error saving archive location %w", err)
+		}
+
+		fmt.Println("Archived :", post.Title)
+	}
+
+	return nil
+}
+
+/* HandlerRead shows a post as text, e.g. gator read {post_url}. It reads the live page first, and if
+the original is gone (404, site down, ...) it falls back to the archived copy from gator archive. */
+
+func HandlerRead(s *state.State, cmd Clicommand, user database.User) error {
+
+	if len(cmd.Argument) == 0 {
+		return fmt.Errorf("the handler expects a single argument, the post url")
+	}
+
+	post, err := s.Db.GetPostByURL(context.Background(), cmd.Argument[0])
+	if err != nil {
+		return fmt.Errorf("error getting the post %w", err)
+	}
+
+	page, err := rss.FetchPage(context.Background(), post.Url)
+	if err != nil {
+		if !post.ArchivePath.Valid {
+			return fmt.Errorf("couldn't read the post and there is no archived copy: %w", err)
+		}
+		fmt.Printf("Original unavailable (%v), reading the archived copy from %s\n", err, post.ArchivePath.String)
+		page, err = os.ReadFile(post.ArchivePath.String)
+		if err != nil {
+			return fmt.Errorf("error reading the archived copy %w", err)
+		}
+	}
+
+	body, err := extract.Article(bytes.NewReader(page), post.Url)
+	if err != nil {
+		// not every page looks like an article, show the whole thing rather than nothing
+		body = string(page)
+	}
+
+	fmt.Println("Post Name :", post.Title)
+	fmt.Println()
+	fmt.Println(render.HTMLToText(body, render.TerminalWidth()))
+
+	return markPostRead(s, user, post.ID)
+}
+
+/* HandlerAddpage adds a page without RSS as a feed, using CSS selectors to find the posts on it:
+gator addpage {name} {url} {item} [title] [link] [date] [summary]
+Pass "" to skip a selector, e.g. gator addpage changelog https://example.com/changes "li.release" "h3" "" "time" */
+
+func HandlerAddpage(s *state.State, cmd Clicommand, user database.User) error {
+
+	if len(cmd.Argument) < 3 {
+		return fmt.Errorf("the handler expects at least three arguments, the feed name, page url and item selector")
+	}
+
+	selector := func(i int) sql.NullString {
+		if i >= len(cmd.Argument) || cmd.Argument[i] == "" {
+			return sql.NullString{}
+		}
+		return sql.NullString{String: cmd.Argument[i], Valid: true}
+	}
+
+	sel := rss.Selectors{
+		Item:    cmd.Argument[2],
+		Title:   selector(3).String,
+		Link:    selector(4).String,
+		Date:    selector(5).String,
+		Summary: selector(6).String,
+	}
+
+	// try the selectors before saving anything, so a typo shows up now and not on the next agg run
+	preview, err := rss.ScrapeHTML(context.Background(), cmd.Argument[1], sel, rss.FetchOptions{})
+	if err != nil {
+		return fmt.Errorf("couldn't scrape the page: %w", err)
+	}
+	fmt.Printf("Found %d items on the page\n", len(preview.Channel.Item))
+
+	current_userid := uuid.NullUUID{UUID: user.ID, Valid: true}
+
+	// one transaction, so a failure never leaves an "rss" feed behind that points at an html page
+	var feed database.Feed
+	var feed_follows database.CreateFeedFollowRow
+	err = s.Db.InTx(context.Background(), func(q database.Store) error {
+		var err error
+		feed, err = q.CreateFeed(context.Background(), database.CreateFeedParams{ID: uuid.New(),
+			CreatedAt: time.Now(), UpdatedAt: time.Now(), Name: cmd.Argument[0], Url: cmd.Argument[1], UserID: current_userid})
+		if err != nil {
+			return fmt.Errorf("couldn't create the feed: %w", err)
+		}
+
+		err = q.SetFeedSelectors(context.Background(), database.SetFeedSelectorsParams{
+			ID:              feed.ID,
+			ItemSelector:    selector(2),
+			TitleSelector:   selector(3),
+			LinkSelector:    selector(4),
+			DateSelector:    selector(5),
+			SummarySelector: selector(6),
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't save the selectors: %w", err)
+		}
+
+		current_feedid := uuid.NullUUID{UUID: feed.ID, Valid: true}
+
+		feed_follows, err = q.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{ID: uuid.New(),
+			CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: current_userid, FeedID: current_feedid})
+		if err != nil {
+			return fmt.Errorf("error in following feed %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(rss.RedactURL(feed.Url))
+	fmt.Println(feed_follows)
+
+	return nil
+}
+
+/* HandlerBackfill imports a feed's older posts, which a normal fetch never sees because feeds only list
+their latest items. It walks back through RFC 5005 prev-archive / next links, or WordPress ?paged=N
+pages, until it runs out of pages or hits the cap: gator backfill {url} [max_pages], default 10 pages.
+Posts we already have are skipped, so running it twice is harmless. */
+
+func HandlerBackfill(s *state.State, cmd Clicommand, user database.User) error {
+
+	if len(cmd.Argument) == 0 {
+		return fmt.Errorf("the handler expects a feed url and optionally the max number of pages")
+	}
+
+	maxPages := 10
+	if len(cmd.Argument) > 1 {
+		parsed, err := strconv.Atoi(cmd.Argument[1])
+		if err != nil || parsed < 1 {
+			return fmt.Errorf("invalid max pages %q", cmd.Argument[1])
+		}
+		maxPages = parsed
+	}
+
+	feed, _, err := feedFor(s, cmd.Argument[0])
+	if err != nil {
+		return fmt.Errorf("error getting feed name %w", err)
+	}
+	if feed.Kind == "html" {
+		return fmt.Errorf("%s is a scraped page, it has no history to backfill", feed.Name)
+	}
+	if feed.Kind == "stdin" {
+		return fmt.Errorf("%s is filled by gator ingest, it has no history to backfill", feed.Name)
+	}
+
+	opts, err := fetchOptions(s, feed)
+	if err != nil {
+		return err
+	}
+
+	pageURL := feed.Url
+	visited := map[string]bool{}
+	wordpress := false
+	total := 0
+
+	for page := 1; page <= maxPages && pageURL != "" && !visited[pageURL]; page++ {
+		visited[pageURL] = true
+
+		// each page is stored a batch at a time as it downloads, like a normal fetch
+		batch := newPostBatch(s.Db, feed)
+		// the next pages are links the feed picks, its credentials only go along to its own site
+		pageOpts := opts
+		if !rss.SameSite(feed.Url, pageURL) {
+			pageOpts.Auth = nil
+		}
+		result, err := rss.StreamFeed(context.Background(), pageURL, pageOpts, maxFeedItems(s), batch.add)
+		if err == nil {
+			err = batch.flush(s.Db)
+		}
+		var status *rss.StatusError
+		if err != nil && wordpress && errors.As(err, &status) && status.StatusCode == http.StatusNotFound {
+			// WordPress answers 404 past the last page, that's the normal way to stop
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("error fetching page %d (%s) %w", page, pageURL, err)
+		}
+		total += batch.created
+		fetchFullTexts(s, feed, batch.fullText)
+		if batch.read == 0 {
+			break
+		}
+
+		fmt.Printf("Page %d: %d items, %d new\n", page, batch.read, batch.created)
+
+		next := result.NextPage(pageURL)
+		if next == "" && (wordpress || (page == 1 && result.IsWordPress())) {
+			wordpress = true
+			next, err = rss.WordPressPage(feed.Url, page+1)
+			if err != nil {
+				return fmt.Errorf("error building the next page url %w", err)
+			}
+		}
+		pageURL = next
+	}
+
+	fmt.Printf("Backfilled %d posts for %s\n", total, feed.Name)
+
+	return nil
+}
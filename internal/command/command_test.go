@@ -0,0 +1,1049 @@
+package command
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	rss "github.com/azhagan2/blog_aggregator/internal/RSS"
+	"github.com/azhagan2/blog_aggregator/internal/config"
+	"github.com/azhagan2/blog_aggregator/internal/database"
+	"github.com/azhagan2/blog_aggregator/internal/secret"
+	"github.com/azhagan2/blog_aggregator/internal/state"
+	"github.com/azhagan2/blog_aggregator/internal/storage/memory"
+)
+
+// newTestState is a state on an empty in-memory database with alice registered and logged in
+func newTestState(t *testing.T) (*state.State, database.User) {
+	t.Helper()
+	s := state.New(&config.Config{CurrentUserName: "alice"}, nil, memory.New())
+	user := createUser(t, s, "alice")
+	return s, user
+}
+
+func createUser(t *testing.T, s *state.State, name string) database.User {
+	t.Helper()
+	user, err := s.Db.CreateUser(context.Background(), database.CreateUserParams{
+		ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), Name: name})
+	if err != nil {
+		t.Fatalf("CreateUser(%s): %v", name, err)
+	}
+	return user
+}
+
+// serveFixture serves testdata/name at /feed.xml
+func serveFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/feed.xml" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL + "/feed.xml"
+}
+
+// captureStdout runs f and returns what it printed
+func captureStdout(t *testing.T, f func() error) (string, error) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	out := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- string(data)
+	}()
+
+	err = f()
+	w.Close()
+	return <-out, err
+}
+
+func run(t *testing.T, f func() error) {
+	t.Helper()
+	if _, err := captureStdout(t, f); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAddfeed(t *testing.T) {
+	s, alice := newTestState(t)
+	feedURL := serveFixture(t, "blog.xml")
+
+	run(t, func() error {
+		return HandlerAddfeed(s, Clicommand{Name: "addfeed", Argument: []string{"blog", feedURL}}, alice)
+	})
+
+	feed, err := s.Db.GetFeed_ByURL(context.Background(), feedURL)
+	if err != nil {
+		t.Fatalf("feed not created: %v", err)
+	}
+	if feed.Name != "blog" || feed.UserID.UUID != alice.ID {
+		t.Errorf("feed = %q by %v, want blog by alice", feed.Name, feed.UserID.UUID)
+	}
+
+	follows, err := s.Db.GetFeedFollowsForUser(context.Background(), database.GetFeedFollowsForUserParams{Name: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(follows) != 1 || follows[0].Name != "blog" {
+		t.Errorf("alice follows %v, want just blog", follows)
+	}
+
+	_, err = captureStdout(t, func() error {
+		return HandlerAddfeed(s, Clicommand{Name: "addfeed", Argument: []string{"blog again", feedURL}}, alice)
+	})
+	if err == nil {
+		t.Error("adding the same url twice should fail")
+	}
+}
+
+func TestFollow(t *testing.T) {
+	s, alice := newTestState(t)
+	feedURL := serveFixture(t, "blog.xml")
+	run(t, func() error {
+		return HandlerAddfeed(s, Clicommand{Name: "addfeed", Argument: []string{"blog", feedURL}}, alice)
+	})
+
+	bob := createUser(t, s, "bob")
+	s.Cfg.CurrentUserName = "bob"
+	out, err := captureStdout(t, func() error {
+		return HandlerFollow(s, Clicommand{Name: "follow", Argument: []string{feedURL}}, bob)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "Followed by : bob") {
+		t.Errorf("output doesn't say bob followed:\n%s", out)
+	}
+
+	follows, err := s.Db.GetFeedFollowsForUser(context.Background(), database.GetFeedFollowsForUserParams{Name: "bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(follows) != 1 || follows[0].Name != "blog" {
+		t.Errorf("bob follows %v, want just blog", follows)
+	}
+
+	_, err = captureStdout(t, func() error {
+		return HandlerFollow(s, Clicommand{Name: "follow", Argument: []string{feedURL}}, bob)
+	})
+	if err == nil {
+		t.Error("following the same feed twice should fail")
+	}
+
+	_, err = captureStdout(t, func() error {
+		return HandlerFollow(s, Clicommand{Name: "follow", Argument: []string{feedURL + "?missing"}}, bob)
+	})
+	if err == nil {
+		t.Error("following a feed nobody added should fail")
+	}
+}
+
+func TestScrapeFeeds(t *testing.T) {
+	s, alice := newTestState(t)
+	feedURL := serveFixture(t, "blog.xml")
+	run(t, func() error {
+		return HandlerAddfeed(s, Clicommand{Name: "addfeed", Argument: []string{"blog", feedURL}}, alice)
+	})
+
+	run(t, func() error { return scrapeFeeds(s) })
+
+	feed, err := s.Db.GetFeed_ByURL(context.Background(), feedURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !feed.LastFetchedAt.Valid {
+		t.Error("feed not marked as fetched")
+	}
+	if feed.Title.String != "Example Blog" || feed.SiteUrl.String != "https://blog.example.com/" ||
+		feed.Description.String != "Posts about examples" || feed.Language.String != "en-us" {
+		t.Errorf("metadata = %q, %q, %q, %q", feed.Title.String, feed.SiteUrl.String, feed.Description.String, feed.Language.String)
+	}
+	if feed.ImageUrl.String != "https://blog.example.com/logo.png" {
+		t.Errorf("image = %q", feed.ImageUrl.String)
+	}
+
+	for _, link := range []string{"https://blog.example.com/first", "https://blog.example.com/second"} {
+		post, err := s.Db.GetPostByURL(context.Background(), link)
+		if err != nil {
+			t.Errorf("post %s not created: %v", link, err)
+			continue
+		}
+		if post.FeedID.UUID != feed.ID || !post.PublishedAt.Valid {
+			t.Errorf("post %s: feed %v, published %v", link, post.FeedID.UUID, post.PublishedAt)
+		}
+	}
+
+	// the second run finds the same items and keeps the posts it has
+	out, err := captureStdout(t, func() error { return scrapeFeeds(s) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(out, "Post already exists") != 2 {
+		t.Errorf("second run should skip both posts:\n%s", out)
+	}
+	posts, err := s.Db.GetPostsForUser(context.Background(), database.GetPostsForUserParams{
+		UserID: uuid.NullUUID{UUID: alice.ID, Valid: true}, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 2 {
+		t.Errorf("got %d posts after two runs, want 2", len(posts))
+	}
+}
+
+func TestScrapeFeedsRefreshesMetadata(t *testing.T) {
+	title, description := "Old Title", "The old blog"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<rss version="2.0"><channel><title>%s</title><link>https://renamed.example.com/</link>
+<description>%s</description><item><title>Post</title><link>https://renamed.example.com/1</link></item></channel></rss>`, title, description)
+	}))
+	defer server.Close()
+
+	s, alice := newTestState(t)
+	run(t, func() error {
+		return HandlerAddfeed(s, Clicommand{Name: "addfeed", Argument: []string{"my blog", server.URL}}, alice)
+	})
+
+	for _, channel := range []struct{ title, description string }{
+		{"Old Title", "The old blog"},
+		// the feed renamed itself, the next fetch picks that up
+		{"New Title", "The new blog"},
+	} {
+		title, description = channel.title, channel.description
+		run(t, func() error { return scrapeFeeds(s) })
+
+		for _, list := range []func() error{
+			func() error { return HandlerFeeds(s, Clicommand{Name: "feeds"}) },
+			func() error { return HandlerFollowing(s, Clicommand{Name: "following"}, alice) },
+		} {
+			out, err := captureStdout(t, list)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the name given at addfeed stays, the feed's own title is shown next to it
+			for _, want := range []string{"my blog\n", "Title : " + channel.title, "Description : " + channel.description,
+				"Homepage : https://renamed.example.com/"} {
+				if !strings.Contains(out, want) {
+					t.Errorf("after fetching %q the output is missing %q:\n%s", channel.title, want, out)
+				}
+			}
+			if channel.title == "New Title" && strings.Contains(out, "Old Title") {
+				t.Errorf("the old title is still shown:\n%s", out)
+			}
+		}
+	}
+
+	feed, err := s.Db.GetFeed_ByURL(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.Name != "my blog" || feed.Title.String != "New Title" {
+		t.Errorf("feed name %q, title %q, want my blog and New Title", feed.Name, feed.Title.String)
+	}
+}
+
+func TestScrapeFeedsNothingToFetch(t *testing.T) {
+	s, _ := newTestState(t)
+	_, err := captureStdout(t, func() error { return scrapeFeeds(s) })
+	if err == nil {
+		t.Error("scrapeFeeds with no feeds should fail")
+	}
+}
+
+func TestBrowse(t *testing.T) {
+	s, alice := newTestState(t)
+	feedURL := serveFixture(t, "blog.xml")
+	run(t, func() error {
+		return HandlerAddfeed(s, Clicommand{Name: "addfeed", Argument: []string{"blog", feedURL}}, alice)
+	})
+	run(t, func() error { return scrapeFeeds(s) })
+
+	out, err := captureStdout(t, func() error {
+		return HandlerBrowse(s, Clicommand{Name: "browse", Argument: []string{"1"}}, alice)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "Found 1 unread posts") || !strings.Contains(out, "Post Name : Second post") {
+		t.Errorf("browse 1 should show just the newest post:\n%s", out)
+	}
+	if !strings.Contains(out, "The second one.") || strings.Contains(out, "<b>") {
+		t.Errorf("description should be rendered as text:\n%s", out)
+	}
+
+	// the post shown is read now, the next browse moves on
+	out, err = captureStdout(t, func() error {
+		return HandlerBrowse(s, Clicommand{Name: "browse", Argument: []string{"10"}}, alice)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "Found 1 unread posts") || !strings.Contains(out, "Post Name : First post") {
+		t.Errorf("the second browse should show only the unread post:\n%s", out)
+	}
+
+	out, err = captureStdout(t, func() error {
+		return HandlerBrowse(s, Clicommand{Name: "browse", Argument: []string{"--all", "10"}}, alice)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "Found 2 posts") || strings.Index(out, "Second post") > strings.Index(out, "First post") {
+		t.Errorf("browse --all should show both posts, newest first:\n%s", out)
+	}
+
+	// bob follows nothing, so there's nothing to browse
+	bob := createUser(t, s, "bob")
+	out, err = captureStdout(t, func() error {
+		return HandlerBrowse(s, Clicommand{Name: "browse"}, bob)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "Found 0 unread posts") {
+		t.Errorf("bob should have no posts:\n%s", out)
+	}
+}
+
+func TestMarkRead(t *testing.T) {
+	s, alice := newTestState(t)
+	feedURL := serveFixture(t, "blog.xml")
+	run(t, func() error {
+		return HandlerAddfeed(s, Clicommand{Name: "addfeed", Argument: []string{"blog", feedURL}}, alice)
+	})
+	run(t, func() error { return scrapeFeeds(s) })
+
+	unread := func() string {
+		t.Helper()
+		out, err := captureStdout(t, func() error {
+			return HandlerFollowing(s, Clicommand{Name: "following"}, alice)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range strings.Split(out, "\n") {
+			if strings.HasPrefix(line, "Unread : ") {
+				return strings.TrimPrefix(line, "Unread : ")
+			}
+		}
+		t.Fatalf("following doesn't show the unread count:\n%s", out)
+		return ""
+	}
+
+	steps := []struct {
+		handler func(*state.State, Clicommand, database.User) error
+		args    []string
+		unread  string
+	}{
+		{nil, nil, "2"},
+		{HandlerMarkRead, []string{"https://blog.example.com/first"}, "1"},
+		{HandlerMarkRead, []string{"https://blog.example.com/first"}, "1"},
+		{HandlerMarkUnread, []string{"https://blog.example.com/first"}, "2"},
+		{HandlerMarkRead, []string{"--feed", feedURL}, "0"},
+		{HandlerMarkUnread, []string{"--before", "2024-01-02"}, "1"},
+		{HandlerMarkUnread, []string{"--feed", feedURL}, "2"},
+		{HandlerMarkRead, []string{"--before", "2024-01-03"}, "0"},
+	}
+	for _, step := range steps {
+		if step.handler != nil {
+			run(t, func() error { return step.handler(s, Clicommand{Argument: step.args}, alice) })
+		}
+		if got := unread(); got != step.unread {
+			t.Fatalf("after %v: %s unread, want %s", step.args, got, step.unread)
+		}
+	}
+
+	for _, args := range [][]string{{}, {"--feed"}, {"--before", "soon"}, {"https://blog.example.com/missing"}} {
+		_, err := captureStdout(t, func() error { return HandlerMarkRead(s, Clicommand{Argument: args}, alice) })
+		if err == nil {
+			t.Errorf("markread %v should fail", args)
+		}
+	}
+}
+
+func TestScrapeFeedsRollsBack(t *testing.T) {
+	s, alice := newTestState(t)
+	feedURL := serveFixture(t, "broken.xml")
+	run(t, func() error {
+		return HandlerAddfeed(s, Clicommand{Name: "addfeed", Argument: []string{"broken", feedURL}}, alice)
+	})
+
+	_, err := captureStdout(t, func() error { return scrapeFeeds(s) })
+	if err == nil {
+		t.Fatal("scraping a cut off feed should fail")
+	}
+
+	if _, err := s.Db.GetPostByURL(context.Background(), "https://broken.example.com/fine"); err == nil {
+		t.Error("a failed fetch shouldn't keep the posts of its unfinished batch")
+	}
+	feed, err := s.Db.GetFeed_ByURL(context.Background(), feedURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.Title.Valid {
+		t.Errorf("a failed fetch shouldn't save metadata, title = %q", feed.Title.String)
+	}
+	if !feed.LastFetchedAt.Valid {
+		t.Error("a failed feed should still go to the back of the queue")
+	}
+}
+
+func TestScrapeFeedsKeepsStoredBatches(t *testing.T) {
+	// a batch and a bit of items, then the connection drops
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<rss version="2.0"><channel><title>Huge</title>`)
+		for i := 0; i < postBatchSize+10; i++ {
+			fmt.Fprintf(w, `<item><title>Post %[1]d</title><link>https://huge.example.com/%[1]d</link></item>`, i)
+		}
+		fmt.Fprint(w, `<item><title>Cut`)
+	}))
+	defer server.Close()
+
+	s, alice := newTestState(t)
+	run(t, func() error {
+		return HandlerAddfeed(s, Clicommand{Name: "addfeed", Argument: []string{"huge", server.URL}}, alice)
+	})
+	if _, err := captureStdout(t, func() error { return scrapeFeeds(s) }); err == nil {
+		t.Fatal("scraping a cut off feed should fail")
+	}
+
+	// the first batch went in while the rest downloaded, the one after it is lost with the fetch
+	if _, err := s.Db.GetPostByURL(context.Background(), fmt.Sprintf("https://huge.example.com/%d", postBatchSize-1)); err != nil {
+		t.Errorf("the stored batch is gone: %v", err)
+	}
+	if _, err := s.Db.GetPostByURL(context.Background(), fmt.Sprintf("https://huge.example.com/%d", postBatchSize)); err == nil {
+		t.Error("the unfinished batch was kept")
+	}
+	feed, err := s.Db.GetFeed_ByURL(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.Title.Valid {
+		t.Errorf("a failed fetch shouldn't save metadata, title = %q", feed.Title.String)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	s, alice := newTestState(t)
+	blogURL := serveFixture(t, "blog.xml")
+	newsURL := serveFixture(t, "news.xml")
+	run(t, func() error {
+		return HandlerAddfeed(s, Clicommand{Name: "addfeed", Argument: []string{"blog", blogURL}}, alice)
+	})
+
+	out, err := captureStdout(t, func() error { return HandlerInspect(s, Clicommand{Name: "inspect", Argument: []string{blogURL}}) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Known feed : blog", "Status : 200 OK", "Format : RSS 2.0", "Title : Example Blog", "Items : 2",
+		"missing guid", "[new] Second post", "[new] First post"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("inspect output is missing %q:\n%s", want, out)
+		}
+	}
+	run(t, func() error { return HandlerInspect(s, Clicommand{Name: "inspect", Argument: []string{newsURL}}) })
+
+	// inspecting stores nothing: no posts, no metadata, no fetch time, no new feed
+	for _, link := range []string{"https://blog.example.com/first", "https://blog.example.com/second"} {
+		if _, err := s.Db.GetPostByURL(context.Background(), link); err == nil {
+			t.Errorf("inspect stored %s", link)
+		}
+	}
+	feed, err := s.Db.GetFeed_ByURL(context.Background(), blogURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.LastFetchedAt.Valid || feed.Title.Valid {
+		t.Errorf("inspect changed the feed: fetched %v, title %q", feed.LastFetchedAt, feed.Title.String)
+	}
+	if feeds, err := s.Db.GetFeeds(context.Background()); err != nil || len(feeds) != 1 {
+		t.Errorf("inspect added a feed: %d feeds (%v)", len(feeds), err)
+	}
+
+	run(t, func() error { return scrapeFeeds(s) })
+	out, err = captureStdout(t, func() error { return HandlerInspect(s, Clicommand{Name: "inspect", Argument: []string{blogURL}}) })
+	if err != nil || strings.Count(out, "[already stored]") != 2 {
+		t.Errorf("after a fetch both posts should be stored (%v):\n%s", err, out)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	s, alice := newTestState(t)
+	feedURL := serveFixture(t, "blog.xml")
+	run(t, func() error {
+		return HandlerAddfeed(s, Clicommand{Name: "addfeed", Argument: []string{"blog", feedURL}}, alice)
+	})
+	run(t, func() error { return scrapeFeeds(s) })
+
+	tests := []struct {
+		args  []string
+		found []string
+	}{
+		{[]string{"second"}, []string{"Second post"}},
+		{[]string{"post", "-second"}, []string{"First post"}},
+		{[]string{"first", "OR", "second"}, []string{"First post", "Second post"}},
+		{[]string{"post", "--since", "2024-01-02"}, []string{"Second post"}},
+		{[]string{"--until", "2024-01-01", "post"}, []string{"First post"}},
+		{[]string{"post", "--feed", "other"}, nil},
+		{[]string{"--following", "post", "--limit", "1"}, []string{"Second post"}},
+	}
+	for _, tt := range tests {
+		out, err := captureStdout(t, func() error {
+			return HandlerSearch(s, Clicommand{Name: "search", Argument: tt.args}, alice)
+		})
+		if err != nil {
+			t.Errorf("search %v: %v", tt.args, err)
+			continue
+		}
+		if !strings.Contains(out, fmt.Sprintf("Found %d posts", len(tt.found))) {
+			t.Errorf("search %v should find %d posts:\n%s", tt.args, len(tt.found), out)
+		}
+		for _, title := range tt.found {
+			if !strings.Contains(out, "Post Name : "+title) {
+				t.Errorf("search %v should find %q:\n%s", tt.args, title, out)
+			}
+		}
+	}
+
+	out, err := captureStdout(t, func() error {
+		return HandlerSearch(s, Clicommand{Name: "search", Argument: []string{"second"}}, alice)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "The «second» one.") {
+		t.Errorf("the snippet should mark the match and drop the markup:\n%s", out)
+	}
+
+	for _, args := range [][]string{{}, {"-java"}, {"post", "--since", "yesterday"}, {"post", "--limit"}} {
+		_, err := captureStdout(t, func() error {
+			return HandlerSearch(s, Clicommand{Name: "search", Argument: args}, alice)
+		})
+		if err == nil {
+			t.Errorf("search %v should fail", args)
+		}
+	}
+}
+
+func TestBookmarks(t *testing.T) {
+	s, alice := newTestState(t)
+	feedURL := serveFixture(t, "blog.xml")
+	run(t, func() error {
+		return HandlerAddfeed(s, Clicommand{Name: "addfeed", Argument: []string{"blog", feedURL}}, alice)
+	})
+	run(t, func() error { return scrapeFeeds(s) })
+
+	save := func(args ...string) {
+		t.Helper()
+		run(t, func() error { return HandlerSave(s, Clicommand{Name: "save", Argument: args}, alice) })
+	}
+	saved := func(args ...string) string {
+		t.Helper()
+		out, err := captureStdout(t, func() error { return HandlerSaved(s, Clicommand{Name: "saved", Argument: args}, alice) })
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	save("https://blog.example.com/first", "--note", "read this again", "--tag", "Go, #later")
+	save("https://blog.example.com/second", "--tag", "go")
+	// saving again adds the tag and keeps the note
+	save("https://blog.example.com/first", "--tag", "extra")
+
+	out := saved()
+	for _, want := range []string{"Found 2 saved posts", "Feed Name : blog", "Note : read this again", "Tags : extra, go, later", "Tags : go"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("saved output is missing %q:\n%s", want, out)
+		}
+	}
+	if out := saved("--tag", "#Later"); !strings.Contains(out, "Found 1 saved posts") || !strings.Contains(out, "First post") {
+		t.Errorf("saved --tag later should list just the first post:\n%s", out)
+	}
+
+	// saved posts are per user
+	bob := createUser(t, s, "bob")
+	out, err := captureStdout(t, func() error { return HandlerSaved(s, Clicommand{Name: "saved"}, bob) })
+	if err != nil || !strings.Contains(out, "Found 0 saved posts") {
+		t.Errorf("bob should have no saved posts (%v):\n%s", err, out)
+	}
+
+	run(t, func() error {
+		return HandlerUnsave(s, Clicommand{Name: "unsave", Argument: []string{"https://blog.example.com/second"}}, alice)
+	})
+	if out := saved(); !strings.Contains(out, "Found 1 saved posts") || strings.Contains(out, "Second post") {
+		t.Errorf("the second post should be gone after unsave:\n%s", out)
+	}
+
+	for _, args := range [][]string{{}, {"https://blog.example.com/missing"}, {"https://blog.example.com/first", "--note"}} {
+		if _, err := captureStdout(t, func() error { return HandlerSave(s, Clicommand{Argument: args}, alice) }); err == nil {
+			t.Errorf("save %v should fail", args)
+		}
+	}
+	if _, err := captureStdout(t, func() error {
+		return HandlerUnsave(s, Clicommand{Argument: []string{"https://blog.example.com/second"}}, alice)
+	}); err == nil {
+		t.Error("unsave of a post that isn't saved should fail")
+	}
+}
+
+func TestFolders(t *testing.T) {
+	s, alice := newTestState(t)
+	blogURL := serveFixture(t, "blog.xml")
+	newsURL := serveFixture(t, "news.xml")
+	run(t, func() error {
+		return HandlerAddfeed(s, Clicommand{Name: "addfeed", Argument: []string{"blog", blogURL}}, alice)
+	})
+	run(t, func() error {
+		return HandlerAddfeed(s, Clicommand{Name: "addfeed", Argument: []string{"news", newsURL}}, alice)
+	})
+	run(t, func() error { return scrapeFeeds(s) })
+	run(t, func() error { return scrapeFeeds(s) })
+
+	run(t, func() error {
+		return HandlerFolder(s, Clicommand{Name: "folder", Argument: []string{blogURL, "Go"}}, alice)
+	})
+
+	out, err := captureStdout(t, func() error { return HandlerFollowing(s, Clicommand{Name: "following"}, alice) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "Folder : Go (2 unread)") || strings.Index(out, "news") > strings.Index(out, "Folder : Go") {
+		t.Errorf("following should list news, then blog in the Go folder:\n%s", out)
+	}
+
+	// folder names match without case
+	out, err = captureStdout(t, func() error {
+		return HandlerFollowing(s, Clicommand{Name: "following", Argument: []string{"--folder", "go"}}, alice)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "blog") || strings.Contains(out, "news") {
+		t.Errorf("following --folder go should list just blog:\n%s", out)
+	}
+
+	out, err = captureStdout(t, func() error {
+		return HandlerBrowse(s, Clicommand{Name: "browse", Argument: []string{"10", "--folder", "Go"}}, alice)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "Found 2 unread posts") || strings.Contains(out, "Breaking news") {
+		t.Errorf("browse --folder Go should show just the blog posts:\n%s", out)
+	}
+
+	// export and import into bob's follows, with the folders
+	file := t.TempDir() + "/feeds.opml"
+	run(t, func() error { return HandlerExport(s, Clicommand{Name: "export", Argument: []string{file}}, alice) })
+
+	bob := createUser(t, s, "bob")
+	s.Cfg.CurrentUserName = "bob"
+	run(t, func() error { return HandlerImport(s, Clicommand{Name: "import", Argument: []string{file}}, bob) })
+
+	follows, err := s.Db.GetFeedFollowsForUser(context.Background(), database.GetFeedFollowsForUserParams{Name: "bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(follows) != 2 || follows[0].Name != "news" || follows[0].Folder.Valid || follows[1].Name != "blog" || follows[1].Folder.String != "Go" {
+		t.Errorf("bob follows %+v, want news and blog in Go", follows)
+	}
+
+	// an OPML file from another reader: a new feed whose name is taken, and a folder for one bob follows
+	other := t.TempDir() + "/other.opml"
+	err = os.WriteFile(other, []byte(`<opml version="1.0"><body>
+<outline text="Friends">
+  <outline text="blog" type="rss" xmlUrl="https://friend.example.com/rss"/>
+  <outline text="News" type="rss" xmlUrl="`+newsURL+`"/>
+</outline>
+</body></opml>`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run(t, func() error { return HandlerImport(s, Clicommand{Name: "import", Argument: []string{other}}, bob) })
+
+	follows, err = s.Db.GetFeedFollowsForUser(context.Background(), database.GetFeedFollowsForUserParams{
+		Name: "bob", Folder: sql.NullString{String: "friends", Valid: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(follows) != 2 || follows[0].Name != "blog (friend.example.com)" || follows[1].Name != "news" {
+		t.Errorf("bob's Friends folder has %+v, want the friend's blog and news", follows)
+	}
+
+	for _, args := range [][]string{{}, {"https://nowhere.example.com/rss", "Go"}} {
+		if _, err := captureStdout(t, func() error { return HandlerFolder(s, Clicommand{Argument: args}, alice) }); err == nil {
+			t.Errorf("folder %v should fail", args)
+		}
+	}
+}
+
+func TestSubscribeToHubRenewal(t *testing.T) {
+	s, alice := newTestState(t)
+	feedURL := serveFixture(t, "blog.xml")
+	run(t, func() error {
+		return HandlerAddfeed(s, Clicommand{Name: "addfeed", Argument: []string{"blog", feedURL}}, alice)
+	})
+	feed, err := s.Db.GetFeed_ByURL(context.Background(), feedURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var secrets []string
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		secrets = append(secrets, r.PostForm.Get("hub.secret"))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer hub.Close()
+
+	s.Cfg.WebSubListen = ":0"
+	s.Cfg.WebSubCallbackURL = "https://gator.example.com"
+	result := &rss.RSSFeed{}
+	result.Channel.AtomLinks = []rss.AtomLink{{Rel: "hub", Href: hub.URL}}
+
+	subscribe := func() {
+		t.Helper()
+		run(t, func() error { return subscribeToHub(s, feed, result) })
+	}
+
+	subscribe()
+	// not verified yet, the hub isn't asked again within the hour
+	subscribe()
+	if len(secrets) != 1 {
+		t.Fatalf("hub asked %d times, want once", len(secrets))
+	}
+
+	// verified with a lease that's already in the renewal window, still within the hour
+	err = s.Db.SetWebSubLease(context.Background(), database.SetWebSubLeaseParams{FeedID: feed.ID,
+		LeaseExpiresAt: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	subscribe()
+	if len(secrets) != 1 {
+		t.Fatalf("hub asked %d times right after verifying, want once", len(secrets))
+	}
+
+	// two hours later the renewal goes out, with the secret the hub already signs with
+	sub, err := s.Db.GetWebSubSubscription(context.Background(), feed.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Db.UpsertWebSubSubscription(context.Background(), database.UpsertWebSubSubscriptionParams{ID: sub.ID,
+		CreatedAt: sub.CreatedAt, UpdatedAt: time.Now().Add(-2 * time.Hour), FeedID: feed.ID, HubUrl: sub.HubUrl, TopicUrl: sub.TopicUrl, Secret: sub.Secret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	subscribe()
+	subscribe()
+	if len(secrets) != 2 || secrets[1] != secrets[0] {
+		t.Fatalf("renewal sent %d requests with secrets %q, want one more with the same secret", len(secrets)-1, secrets)
+	}
+	if sub, _ := s.Db.GetWebSubSubscription(context.Background(), feed.ID); sub.Secret != secrets[0] {
+		t.Errorf("stored secret changed on renewal")
+	}
+}
+
+func TestIngest(t *testing.T) {
+	s, alice := newTestState(t)
+	feedURL := serveFixture(t, "blog.xml")
+	run(t, func() error {
+		return HandlerAddfeed(s, Clicommand{Name: "addfeed", Argument: []string{"blog", feedURL}}, alice)
+	})
+
+	out, err := captureStdout(t, func() error {
+		return HandlerIngest(s, Clicommand{Name: "ingest", Argument: []string{"testdata/news.xml", "piped"}}, alice)
+	})
+	if err != nil || !strings.Contains(out, "Ingested 1 items into piped, 1 new") {
+		t.Fatalf("first ingest (%v):\n%s", err, out)
+	}
+	out, err = captureStdout(t, func() error {
+		return HandlerIngest(s, Clicommand{Name: "ingest", Argument: []string{"testdata/news.xml", "piped"}}, alice)
+	})
+	if err != nil || !strings.Contains(out, "1 items into piped, 0 new") {
+		t.Errorf("ingesting into the same feed again (%v):\n%s", err, out)
+	}
+
+	// not into a feed gator fetches, nor into another user's ingest feed
+	bob := createUser(t, s, "bob")
+	for _, tt := range []struct {
+		user database.User
+		name string
+	}{{alice, "blog"}, {bob, "piped"}} {
+		_, err := captureStdout(t, func() error {
+			return HandlerIngest(s, Clicommand{Name: "ingest", Argument: []string{"testdata/news.xml", tt.name}}, tt.user)
+		})
+		if err == nil {
+			t.Errorf("%s ingesting into %s should fail", tt.user.Name, tt.name)
+		}
+	}
+}
+
+func TestBackfillWordPress(t *testing.T) {
+	for _, tt := range []struct {
+		lastPage int
+		wantErr  bool
+	}{
+		// WordPress answers 404 past the last page, anything else is a failure
+		{http.StatusNotFound, false},
+		{http.StatusInternalServerError, true},
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			page := r.URL.Query().Get("paged")
+			if page == "3" {
+				w.WriteHeader(tt.lastPage)
+				return
+			}
+			if page == "" {
+				page = "1"
+			}
+			fmt.Fprintf(w, `<rss version="2.0"><channel><title>WP</title><generator>https://wordpress.org/?v=6.4</generator>
+<item><title>Post %[1]s</title><link>https://wp.example.com/%[1]s</link></item></channel></rss>`, page)
+		}))
+		defer server.Close()
+
+		s, alice := newTestState(t)
+		run(t, func() error {
+			return HandlerAddfeed(s, Clicommand{Name: "addfeed", Argument: []string{"wp", server.URL + "/feed/"}}, alice)
+		})
+		out, err := captureStdout(t, func() error {
+			return HandlerBackfill(s, Clicommand{Name: "backfill", Argument: []string{server.URL + "/feed/"}}, alice)
+		})
+		if tt.wantErr {
+			if err == nil || strings.Contains(out, "Backfilled") {
+				t.Errorf("status %d on page 3 should fail the backfill:\n%s", tt.lastPage, out)
+			}
+			continue
+		}
+		if err != nil || !strings.Contains(out, "Backfilled 2 posts for wp") {
+			t.Errorf("backfill (%v):\n%s", err, out)
+		}
+	}
+}
+
+func TestFolderSpelling(t *testing.T) {
+	s, alice := newTestState(t)
+	blogURL := serveFixture(t, "blog.xml")
+	newsURL := serveFixture(t, "news.xml")
+	run(t, func() error {
+		return HandlerAddfeed(s, Clicommand{Name: "addfeed", Argument: []string{"blog", blogURL}}, alice)
+	})
+	run(t, func() error {
+		return HandlerAddfeed(s, Clicommand{Name: "addfeed", Argument: []string{"news", newsURL}}, alice)
+	})
+	run(t, func() error { return scrapeFeeds(s) })
+	run(t, func() error { return scrapeFeeds(s) })
+
+	// "go" is the Go folder alice has already, not a second one
+	run(t, func() error {
+		return HandlerFolder(s, Clicommand{Name: "folder", Argument: []string{blogURL, "Go"}}, alice)
+	})
+	run(t, func() error {
+		return HandlerFolder(s, Clicommand{Name: "folder", Argument: []string{newsURL, "go"}}, alice)
+	})
+	out, err := captureStdout(t, func() error { return HandlerFollowing(s, Clicommand{Name: "following"}, alice) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "Folder : Go (3 unread)") || strings.Contains(out, "Folder : go") {
+		t.Errorf("following should have one Go folder with both feeds:\n%s", out)
+	}
+
+	// the only feed of a folder can change how it's written
+	run(t, func() error {
+		return HandlerFolder(s, Clicommand{Name: "folder", Argument: []string{newsURL}}, alice)
+	})
+	run(t, func() error {
+		return HandlerFolder(s, Clicommand{Name: "folder", Argument: []string{blogURL, "GO"}}, alice)
+	})
+	follows, err := s.Db.GetFeedFollowsForUser(context.Background(), database.GetFeedFollowsForUserParams{Name: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range follows {
+		if f.Name == "blog" && f.Folder.String != "GO" {
+			t.Errorf("blog is in %q, want GO", f.Folder.String)
+		}
+	}
+}
+
+func TestImportOnlyWebFeeds(t *testing.T) {
+	s, alice := newTestState(t)
+	for _, feedURL := range []string{"file:///etc/passwd", "stdin://alice/notes", "ftp://example.com/feed.xml", "https:///feed.xml"} {
+		file := filepath.Join(t.TempDir(), "feeds.opml")
+		err := os.WriteFile(file, []byte(`<opml version="2.0"><body><outline text="x" type="rss" xmlUrl="`+feedURL+`"/></body></opml>`), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = captureStdout(t, func() error { return HandlerImport(s, Clicommand{Name: "import", Argument: []string{file}}, alice) })
+		if err == nil {
+			t.Errorf("importing %s should fail", feedURL)
+		}
+	}
+	feeds, err := s.Db.GetFeeds(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(feeds) != 0 {
+		t.Errorf("the failed imports added %d feeds", len(feeds))
+	}
+}
+
+// offlineFetcher fails every fetch and counts them
+type offlineFetcher struct{ fetches int }
+
+func (f *offlineFetcher) Fetch(ctx context.Context, pageURL string, opts rss.FetchOptions) (*rss.Response, error) {
+	f.fetches++
+	return nil, fmt.Errorf("offline: %s", pageURL)
+}
+
+func TestStoredFeedNeedsNoNetwork(t *testing.T) {
+	s, alice := newTestState(t)
+	const profile = "https://mastodon.example/@alice"
+	feed, err := s.Db.CreateFeed(context.Background(), database.CreateFeedParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(),
+		Name: "toots", Url: profile, UserID: uuid.NullUUID{UUID: alice.ID, Valid: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	offline := &offlineFetcher{}
+	rss.SetFetcher(offline)
+	defer rss.SetFetcher(rss.HTTPFetcher{})
+
+	run(t, func() error { return HandlerFollow(s, Clicommand{Name: "follow", Argument: []string{profile}}, alice) })
+	run(t, func() error {
+		return HandlerFolder(s, Clicommand{Name: "folder", Argument: []string{profile, "Friends"}}, alice)
+	})
+	run(t, func() error {
+		return HandlerUnfollow(s, Clicommand{Name: "unfollow", Argument: []string{profile}}, alice)
+	})
+	if offline.fetches != 0 {
+		t.Errorf("looking up the stored feed %s fetched %d times", feed.Url, offline.fetches)
+	}
+
+	// a url gator doesn't have is still resolved, and then not found
+	_, err = captureStdout(t, func() error {
+		return HandlerUnfollow(s, Clicommand{Name: "unfollow", Argument: []string{"https://mastodon.example/@bob"}}, alice)
+	})
+	if err == nil || offline.fetches == 0 {
+		t.Errorf("unknown profile: err %v after %d fetches, want a lookup and an error", err, offline.fetches)
+	}
+}
+
+func TestFeedCredentials(t *testing.T) {
+	// the credentials each request brought, by host
+	seen := map[string][]string{}
+	credentials := func(r *http.Request) string {
+		return r.Header.Get("Authorization") + " " + r.Header.Get("X-Api-Key") + " " + r.URL.Query().Get("token")
+	}
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen["other"] = append(seen["other"], credentials(r))
+		w.Write([]byte(`<rss version="2.0"><channel><title>Elsewhere</title></channel></rss>`))
+	}))
+	defer other.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen["feed"] = append(seen["feed"], credentials(r))
+		fmt.Fprintf(w, `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom"><channel><title>Private</title>
+<atom:link rel="prev-archive" href="%s/2023.xml"/>
+<item><title>Secret plans</title><link>https://private.example.com/1</link></item></channel></rss>`, other.URL)
+	}))
+	defer server.Close()
+	feedURL := server.URL + "/feed.xml"
+
+	s, alice := newTestState(t)
+	key, err := secret.NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// with a key already there feedauth doesn't write a config file
+	s.Cfg.SecretKey = key
+	run(t, func() error {
+		return HandlerAddfeed(s, Clicommand{Name: "addfeed", Argument: []string{"private", feedURL}}, alice)
+	})
+
+	secrets := []string{"bearer-secret", "hunter2", "query-secret", "header-secret"}
+	var out strings.Builder
+	for _, args := range [][]string{
+		{"bearer", "bearer-secret"},
+		// basic replaces the bearer token, a request only has one Authorization
+		{"basic", "ann", "hunter2"},
+		{"query", "token", "query-secret"},
+		{"header", "X-Api-Key", "header-secret"},
+	} {
+		printed, err := captureStdout(t, func() error {
+			return HandlerFeedAuth(s, Clicommand{Name: "feedauth", Argument: append([]string{feedURL}, args...)}, alice)
+		})
+		if err != nil {
+			t.Fatalf("feedauth %s: %v", args[0], err)
+		}
+		out.WriteString(printed)
+	}
+	if !strings.Contains(out.String(), "basic, query token, header X-Api-Key") {
+		t.Errorf("feedauth should say which credentials the feed has:\n%s", out.String())
+	}
+
+	feed, err := s.Db.GetFeed_ByURL(context.Background(), feedURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, value := range secrets {
+		if strings.Contains(feed.Credentials.String, value) {
+			t.Errorf("%s is stored in the clear", value)
+		}
+	}
+
+	for _, f := range []func() error{
+		func() error { return HandlerFeeds(s, Clicommand{Name: "feeds"}) },
+		func() error { return HandlerFollowing(s, Clicommand{Name: "following"}, alice) },
+		func() error { return scrapeFeeds(s) },
+		func() error {
+			return HandlerBackfill(s, Clicommand{Name: "backfill", Argument: []string{feedURL, "2"}}, alice)
+		},
+	} {
+		printed, err := captureStdout(t, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out.WriteString(printed)
+	}
+	for _, value := range secrets {
+		if strings.Contains(out.String(), value) {
+			t.Errorf("the output shows %s:\n%s", value, out.String())
+		}
+	}
+
+	// the feed gets every credential, the archive page on another host none of them
+	want := "Basic YW5uOmh1bnRlcjI= header-secret query-secret"
+	if len(seen["feed"]) != 2 || seen["feed"][0] != want || seen["feed"][1] != want {
+		t.Errorf("the feed got %q, want %q twice", seen["feed"], want)
+	}
+	if len(seen["other"]) != 1 || strings.TrimSpace(seen["other"][0]) != "" {
+		t.Errorf("the other host got %q, want no credentials", seen["other"])
+	}
+}
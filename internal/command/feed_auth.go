@@ -0,0 +1,122 @@
+package command
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	rss "github.com/azhagan2/blog_aggregator/internal/RSS"
+	"github.com/azhagan2/blog_aggregator/internal/database"
+	"github.com/azhagan2/blog_aggregator/internal/secret"
+	"github.com/azhagan2/blog_aggregator/internal/state"
+)
+
+// feedAuth decrypts a feed's stored credentials, nil if it has none
+
+func feedAuth(s *state.State, feed database.Feed) (*rss.Auth, error) {
+	if !feed.Credentials.Valid {
+		return nil, nil
+	}
+
+	plaintext, err := secret.Decrypt(s.Cfg.SecretKey, feed.Credentials.String)
+	if err != nil {
+		return nil, fmt.Errorf("credentials of %s: %w", feed.Name, err)
+	}
+
+	auth := &rss.Auth{}
+	if err := json.Unmarshal(plaintext, auth); err != nil {
+		return nil, fmt.Errorf("credentials of %s are corrupted: %w", feed.Name, err)
+	}
+	return auth, nil
+}
+
+/* HandlerFeedAuth sets the credentials a private feed needs, they add up until cleared:
+gator feedauth {url} basic {user} {password}
+gator feedauth {url} bearer {token}
+gator feedauth {url} query {name} {value}
+gator feedauth {url} header {name} {value}
+gator feedauth {url} clear
+They're encrypted with secret_key from the config file, which gets generated the first time. */
+
+func HandlerFeedAuth(s *state.State, cmd Clicommand, user database.User) error {
+
+	if len(cmd.Argument) < 2 {
+		return fmt.Errorf("the handler expects a feed url and basic, bearer, query, header or clear")
+	}
+
+	feed, _, err := feedFor(s, cmd.Argument[0])
+	if err != nil {
+		return fmt.Errorf("error getting feed name %w", err)
+	}
+
+	kind, values := cmd.Argument[1], cmd.Argument[2:]
+	if kind == "clear" {
+		err = s.Db.SetFeedCredentials(context.Background(), database.SetFeedCredentialsParams{ID: feed.ID})
+		if err != nil {
+			return fmt.Errorf("error clearing credentials %w", err)
+		}
+		fmt.Println("Credentials cleared for", feed.Name)
+		return nil
+	}
+
+	if s.Cfg.SecretKey == "" {
+		key, err := secret.NewKey()
+		if err != nil {
+			return err
+		}
+		if err := s.Cfg.SetSecretKey(key); err != nil {
+			return fmt.Errorf("error saving the new secret key: %w", err)
+		}
+		fmt.Println("Generated secret_key in the config file, keep a copy: without it stored credentials can't be read")
+	}
+
+	auth, err := feedAuth(s, feed)
+	if err != nil {
+		return err
+	}
+	if auth == nil {
+		auth = &rss.Auth{}
+	}
+
+	switch {
+	case kind == "basic" && len(values) == 2:
+		auth.Username, auth.Password, auth.Token = values[0], values[1], ""
+	case kind == "bearer" && len(values) == 1:
+		auth.Token, auth.Username, auth.Password = values[0], "", ""
+	case kind == "query" && len(values) == 2:
+		if auth.Query == nil {
+			auth.Query = map[string]string{}
+		}
+		auth.Query[values[0]] = values[1]
+	case kind == "header" && len(values) == 2:
+		if auth.Headers == nil {
+			auth.Headers = map[string]string{}
+		}
+		auth.Headers[values[0]] = values[1]
+	default:
+		return errors.New("usage: feedauth {url} basic {user} {password} | bearer {token} | query {name} {value} | header {name} {value} | clear")
+	}
+
+	plaintext, err := json.Marshal(auth)
+	if err != nil {
+		return fmt.Errorf("error encoding credentials %w", err)
+	}
+	ciphertext, err := secret.Encrypt(s.Cfg.SecretKey, plaintext)
+	if err != nil {
+		return err
+	}
+
+	err = s.Db.SetFeedCredentials(context.Background(), database.SetFeedCredentialsParams{
+		ID:          feed.ID,
+		Credentials: sql.NullString{String: ciphertext, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("error saving credentials %w", err)
+	}
+
+	fmt.Printf("Credentials for %s: %s\n", feed.Name, auth.Describe())
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package command
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	rss "github.com/azhagan2/blog_aggregator/internal/RSS"
+	"github.com/azhagan2/blog_aggregator/internal/archive"
+	"github.com/azhagan2/blog_aggregator/internal/database"
+	"github.com/azhagan2/blog_aggregator/internal/state"
+)
+
+/* saveFeedImages keeps the feed's picture up to date after a fetch, and with fetch_favicons on, saves
+the site's favicon into the archive the first time. A site without one gets an empty favicon_path so
+we don't ask again on every fetch. */
+
+func saveFeedImages(s *state.State, feed database.Feed, fetched *rss.RSSFeed) error {
+	image := fetched.ImageURL()
+	if image != feed.ImageUrl.String {
+		err := s.Db.SetFeedImage(context.Background(), database.SetFeedImageParams{
+			ID: feed.ID, ImageUrl: sql.NullString{String: image, Valid: image != ""}})
+		if err != nil {
+			return fmt.Errorf("error saving the feed image %w", err)
+		}
+	}
+
+	if !s.Cfg.FetchFavicons || feed.FaviconPath.Valid {
+		return nil
+	}
+
+	site := siteURL(feed, fetched)
+	if site == "" {
+		return nil
+	}
+
+	dir, err := s.Cfg.ArchivePath()
+	if err != nil {
+		return fmt.Errorf("error finding the archive directory %w", err)
+	}
+
+	path, err := archive.NewStore(dir).SaveFavicon(context.Background(), site)
+	if err != nil && !errors.Is(err, archive.ErrNoFavicon) {
+		return fmt.Errorf("error saving the favicon of %s %w", site, err)
+	}
+
+	return s.Db.SetFeedFavicon(context.Background(), database.SetFeedFaviconParams{
+		ID: feed.ID, FaviconPath: sql.NullString{String: path, Valid: true}})
+}
+
+// siteURL is the homepage the feed belongs to: the channel link, or else the root of the feed's own host
+
+func siteURL(feed database.Feed, fetched *rss.RSSFeed) string {
+	for _, candidate := range []string{fetched.Channel.Link, feed.Url} {
+		u, err := url.Parse(strings.TrimSpace(candidate))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			continue
+		}
+		if candidate == feed.Url {
+			return u.Scheme + "://" + u.Host + "/"
+		}
+		return u.String()
+	}
+	return ""
+}
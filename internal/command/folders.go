@@ -0,0 +1,267 @@
+package command
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/azhagan2/blog_aggregator/internal/database"
+	"github.com/azhagan2/blog_aggregator/internal/opml"
+	"github.com/azhagan2/blog_aggregator/internal/state"
+)
+
+/* HandlerFolder files a followed feed under a folder, or takes it out of its folder when no folder is given:
+gator folder {feed_url} {folder}
+Folders belong to the user's follows, so two users can file the same feed differently. following, browse
+and the unread counts take --folder {folder}, and export/import keep the folders in the OPML file. */
+
+func HandlerFolder(s *state.State, cmd Clicommand, user database.User) error {
+
+	if len(cmd.Argument) == 0 {
+		return fmt.Errorf("the handler expects the feed url, and the folder to put it in")
+	}
+
+	feed, _, err := feedFor(s, cmd.Argument[0])
+	if err != nil {
+		return fmt.Errorf("error getting feed name %w", err)
+	}
+
+	folder := toNullString(strings.TrimSpace(strings.Join(cmd.Argument[1:], " ")))
+	if err := setFolder(s.Db, user, feed, folder); err != nil {
+		return err
+	}
+
+	if folder.Valid {
+		fmt.Printf("Moved %s to %s\n", feed.Name, folder.String)
+	} else {
+		fmt.Printf("Took %s out of its folder\n", feed.Name)
+	}
+	return nil
+}
+
+/* setFolder files the user's follow of feed under folder, an invalid folder means none. Folders match without
+case, so a folder the user already has is written the way it's written there ("go" goes into "Go"), or
+following would list the two spellings as two folders. */
+
+func setFolder(q database.Store, user database.User, feed database.Feed, folder sql.NullString) error {
+	if folder.Valid {
+		others, err := q.GetFeedFollowsForUser(context.Background(), database.GetFeedFollowsForUserParams{Name: user.Name, Folder: folder})
+		if err != nil {
+			return fmt.Errorf("error getting the folder %w", err)
+		}
+		for _, f := range others {
+			// the feed itself doesn't count, that's how a folder of one feed gets a new spelling
+			if f.Url != feed.Url && f.Folder.Valid {
+				folder = f.Folder
+				break
+			}
+		}
+	}
+
+	n, err := q.SetFollowFolder(context.Background(), database.SetFollowFolderParams{
+		Folder: folder,
+		UserID: uuid.NullUUID{UUID: user.ID, Valid: true},
+		FeedID: uuid.NullUUID{UUID: feed.ID, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("error setting the folder %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("you don't follow %s, gator follow {url} first", feed.Name)
+	}
+	return nil
+}
+
+// folderFlag takes --folder {name} out of args, for the commands that can be limited to one folder
+
+func folderFlag(args []string) (sql.NullString, []string, error) {
+	var folder sql.NullString
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		if args[i] != "--folder" {
+			rest = append(rest, args[i])
+			continue
+		}
+		if i+1 >= len(args) || strings.TrimSpace(args[i+1]) == "" {
+			return folder, nil, fmt.Errorf("--folder needs a folder name")
+		}
+		folder = sql.NullString{String: strings.TrimSpace(args[i+1]), Valid: true}
+		i++
+	}
+	return folder, rest, nil
+}
+
+// HandlerExport writes the followed feeds, in their folders, as OPML: gator export [file], stdout without a file
+
+func HandlerExport(s *state.State, cmd Clicommand, user database.User) error {
+
+	follows, err := s.Db.GetFeedFollowsForUser(context.Background(), database.GetFeedFollowsForUserParams{Name: user.Name})
+	if err != nil {
+		return fmt.Errorf("error getting the feeds for the user %w", err)
+	}
+
+	feeds := make([]opml.Feed, 0, len(follows))
+	for _, f := range follows {
+		if strings.HasPrefix(f.Url, "stdin://") {
+			// fed by gator ingest, there's nothing to subscribe to
+			continue
+		}
+		feeds = append(feeds, opml.Feed{Title: f.Name, URL: f.Url, SiteURL: f.SiteUrl.String, Folder: f.Folder.String})
+	}
+
+	var out io.Writer = os.Stdout
+	if len(cmd.Argument) > 0 && cmd.Argument[0] != "-" {
+		file, err := os.Create(cmd.Argument[0])
+		if err != nil {
+			return fmt.Errorf("error creating the export file %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if err := opml.Write(out, "gator subscriptions of "+user.Name, feeds); err != nil {
+		return fmt.Errorf("error writing the OPML %w", err)
+	}
+	if out != os.Stdout {
+		fmt.Printf("Exported %d feeds to %s\n", len(feeds), cmd.Argument[0])
+	}
+	return nil
+}
+
+/* HandlerImport follows every feed of an OPML file, from another reader or gator export: gator import {file|-}
+Feeds gator doesn't know yet are added under the name the file gives them, and follows are filed under
+the file's folders. A feed that's in no folder in the file keeps the folder it has here. It all goes in
+one transaction, so a bad entry leaves nothing half imported. */
+
+func HandlerImport(s *state.State, cmd Clicommand, user database.User) error {
+
+	if len(cmd.Argument) == 0 {
+		return fmt.Errorf("the handler expects an OPML file, or - for stdin")
+	}
+
+	var in io.Reader = os.Stdin
+	if cmd.Argument[0] != "-" {
+		file, err := os.Open(cmd.Argument[0])
+		if err != nil {
+			return fmt.Errorf("error opening the OPML file %w", err)
+		}
+		defer file.Close()
+		in = file
+	}
+
+	feeds, err := opml.Parse(in)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	follows, err := s.Db.GetFeedFollowsForUser(ctx, database.GetFeedFollowsForUserParams{Name: user.Name})
+	if err != nil {
+		return fmt.Errorf("error getting the feeds for the user %w", err)
+	}
+	followed := map[string]bool{}
+	for _, f := range follows {
+		followed[f.Url] = true
+	}
+
+	added, newFollows := 0, 0
+	err = s.Db.InTx(ctx, func(q database.Store) error {
+		for _, f := range feeds {
+			feed, created, err := importFeed(q, user, f)
+			if err != nil {
+				return err
+			}
+			if created {
+				added++
+			}
+
+			if !followed[feed.Url] {
+				_, err := q.CreateFeedFollow(ctx, database.CreateFeedFollowParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(),
+					UserID: uuid.NullUUID{UUID: user.ID, Valid: true}, FeedID: uuid.NullUUID{UUID: feed.ID, Valid: true}})
+				if err != nil {
+					return fmt.Errorf("error in following feed %s %w", feed.Name, err)
+				}
+				followed[feed.Url] = true
+				newFollows++
+			}
+
+			if f.Folder != "" {
+				if err := setFolder(q, user, feed, toNullString(f.Folder)); err != nil {
+					return err
+				}
+			}
+			fmt.Printf("%s : %s\n", feed.Name, f.URL)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Imported %d feeds, %d new to gator and %d newly followed\n", len(feeds), added, newFollows)
+	return nil
+}
+
+// importFeed is the feed at f.URL, added under f.Title when gator doesn't have it yet
+
+func importFeed(q database.Store, user database.User, f opml.Feed) (database.Feed, bool, error) {
+	ctx := context.Background()
+	feed, err := q.GetFeed_ByURL(ctx, f.URL)
+	if err == nil {
+		return feed, false, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return feed, false, fmt.Errorf("error getting feed %s %w", f.URL, err)
+	}
+
+	// only web feeds, an OPML file mustn't make gator read local files (file://) or pose as gator ingest (stdin://)
+	if u, err := url.ParseRequestURI(f.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return feed, false, fmt.Errorf("invalid feed url %q in the OPML file, only http and https feeds can be imported", f.URL)
+	}
+
+	name, err := importName(q, f)
+	if err != nil {
+		return feed, false, err
+	}
+	feed, err = q.CreateFeed(ctx, database.CreateFeedParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(),
+		Name: name, Url: f.URL, UserID: uuid.NullUUID{UUID: user.ID, Valid: true}})
+	if err != nil {
+		return feed, false, fmt.Errorf("couldn't create the feed: %w", err)
+	}
+	return feed, true, nil
+}
+
+// importName is the title from the file, with the host after it if another feed has that name already
+
+func importName(q database.Store, f opml.Feed) (string, error) {
+	names := []string{f.Title}
+	if u, err := url.Parse(f.URL); err == nil && u.Host != "" {
+		if f.Title == "" {
+			names = []string{u.Host}
+		} else {
+			names = append(names, f.Title+" ("+u.Host+")")
+		}
+	}
+
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		_, err := q.GetFeedByName(context.Background(), name)
+		if errors.Is(err, sql.ErrNoRows) {
+			return name, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("error getting feed name %w", err)
+		}
+	}
+	return f.URL, nil
+}
@@ -0,0 +1,105 @@
+package command
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+
+	rss "github.com/azhagan2/blog_aggregator/internal/RSS"
+	"github.com/azhagan2/blog_aggregator/internal/database"
+	"github.com/azhagan2/blog_aggregator/internal/state"
+)
+
+/* HandlerIngest stores the posts of a feed document that doesn't live at a URL, like the output of a
+script: some-script | gator ingest - {feed_name}, or gator ingest {file} {feed_name}. The named feed
+is created (and followed) the first time, and agg leaves it alone since there's nothing to fetch. */
+
+func HandlerIngest(s *state.State, cmd Clicommand, user database.User) error {
+
+	if len(cmd.Argument) < 2 {
+		return fmt.Errorf("the handler expects two arguments, - (or a file) and the feed name")
+	}
+
+	source, name := cmd.Argument[0], cmd.Argument[1]
+
+	var data []byte
+	var err error
+	if source == "-" {
+		data, err = io.ReadAll(io.LimitReader(os.Stdin, rss.MaxBodySize+1))
+	} else {
+		data, err = os.ReadFile(source)
+	}
+	if err != nil {
+		return fmt.Errorf("error reading the feed document %w", err)
+	}
+	if len(data) > rss.MaxBodySize {
+		return fmt.Errorf("the feed document is larger than %d bytes", rss.MaxBodySize)
+	}
+
+	parsed, err := rss.ParseFeed(data)
+	if err != nil {
+		return err
+	}
+
+	// a new feed, its follow and its posts are one transaction, a failed ingest leaves no empty feed behind
+	var feed database.Feed
+	var created []database.Post
+	err = s.Db.InTx(context.Background(), func(q database.Store) error {
+		var err error
+		feed, err = q.GetFeedByName(context.Background(), name)
+		if errors.Is(err, sql.ErrNoRows) {
+			feed, err = createIngestFeed(q, name, user)
+		}
+		if err != nil {
+			return fmt.Errorf("error getting the feed %w", err)
+		}
+		// only into a feed ingest made for this user, never into a fetched feed or someone else's
+		if feed.Kind != "stdin" || !feed.UserID.Valid || feed.UserID.UUID != user.ID {
+			return fmt.Errorf("%s is a feed gator fetches or another user's, pick a new name to ingest into", name)
+		}
+
+		created, err = createPosts(q, feed, parsed.Channel.Item)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	fetchFullTexts(s, feed, created)
+
+	fmt.Printf("Ingested %d items into %s, %d new\n", len(parsed.Channel.Item), feed.Name, len(created))
+
+	return nil
+}
+
+// createIngestFeed makes a feed for ingest, its url (stdin://{name}) only exists because feeds.url has to be unique
+
+func createIngestFeed(q database.Store, name string, user database.User) (database.Feed, error) {
+	current_userid := uuid.NullUUID{UUID: user.ID, Valid: true}
+
+	feed, err := q.CreateFeed(context.Background(), database.CreateFeedParams{ID: uuid.New(),
+		CreatedAt: time.Now(), UpdatedAt: time.Now(), Name: name, Url: "stdin://" + name, UserID: current_userid})
+	if err != nil {
+		return database.Feed{}, fmt.Errorf("couldn't create the feed: %w", err)
+	}
+
+	err = q.SetFeedKind(context.Background(), database.SetFeedKindParams{ID: feed.ID, Kind: "stdin"})
+	if err != nil {
+		return database.Feed{}, err
+	}
+	feed.Kind = "stdin"
+
+	_, err = q.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{ID: uuid.New(),
+		CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: current_userid, FeedID: uuid.NullUUID{UUID: feed.ID, Valid: true}})
+	if err != nil {
+		return database.Feed{}, fmt.Errorf("error in following feed %w", err)
+	}
+
+	fmt.Println("Created feed", name)
+	return feed, nil
+}
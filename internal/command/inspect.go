@@ -0,0 +1,117 @@
+package command
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	rss "github.com/azhagan2/blog_aggregator/internal/RSS"
+	"github.com/azhagan2/blog_aggregator/internal/state"
+)
+
+// The response headers worth looking at when a feed misbehaves: caching and content type
+var inspectHeaders = []string{"Content-Type", "Content-Length", "Cache-Control", "ETag", "Last-Modified", "Expires", "Age", "Retry-After"}
+
+/* HandlerInspect explains what gator sees at a feed URL, without storing anything: gator inspect {url}.
+It fetches with the same fetcher and settings as agg (credentials and network overrides of a known feed
+included), though it reads the whole response instead of streaming it. It prints the status, redirects,
+headers, format, warnings about the items, and which posts a fetch would create. */
+
+func HandlerInspect(s *state.State, cmd Clicommand) error {
+
+	if len(cmd.Argument) == 0 {
+		return fmt.Errorf("the handler expects a single argument, the feed url")
+	}
+
+	opts := rss.FetchOptions{}
+	feed, feedURL, err := feedFor(s, cmd.Argument[0])
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err == nil {
+		fmt.Println("Known feed :", feed.Name)
+		opts, err = fetchOptions(s, feed)
+		if err != nil {
+			return err
+		}
+	}
+
+	report, err := rss.Inspect(context.Background(), feedURL, opts)
+	if err != nil {
+		return fmt.Errorf("couldn't fetch %s: %w", rss.RedactURL(feedURL), err)
+	}
+
+	fmt.Println("URL :", rss.RedactURL(feedURL))
+	for _, hop := range report.Redirects {
+		fmt.Printf("Redirect : %s -> %s\n", rss.RedactURL(hop.From), hop.Status)
+	}
+	if len(report.Redirects) > 0 {
+		fmt.Println("Final URL :", rss.RedactURL(report.URL))
+	}
+	fmt.Println("Status :", report.Status)
+
+	fmt.Println()
+	fmt.Println("Headers :")
+	for _, name := range inspectHeaders {
+		if value := report.Header.Get(name); value != "" {
+			fmt.Printf("  %s: %s\n", name, value)
+		}
+	}
+	if report.Header.Get("ETag") == "" && report.Header.Get("Last-Modified") == "" {
+		fmt.Println("  (no ETag or Last-Modified, every fetch downloads the whole feed)")
+	}
+
+	fmt.Println()
+	fmt.Println("Size :", len(report.Body), "bytes")
+	fmt.Println("Format :", report.Format)
+	charset := report.Charset
+	if charset == "" {
+		charset = "not declared (UTF-8 assumed)"
+	}
+	fmt.Println("Charset :", charset)
+
+	if report.ParseError != nil {
+		fmt.Println("Parse error :", report.ParseError)
+	} else {
+		fmt.Println("Title :", report.Feed.Channel.Title)
+		fmt.Println("Items :", len(report.Feed.Channel.Item))
+		if hub := report.Feed.Hub(); hub != "" {
+			fmt.Println("WebSub hub :", hub)
+		}
+		if next := report.Feed.NextPage(report.URL); next != "" {
+			fmt.Println("Older pages :", next)
+		}
+	}
+
+	fmt.Println()
+	if len(report.Warnings) == 0 {
+		fmt.Println("Warnings : none")
+	} else {
+		fmt.Printf("Warnings : %d\n", len(report.Warnings))
+		for _, warning := range report.Warnings {
+			fmt.Println("  -", warning)
+		}
+	}
+
+	if report.ParseError != nil || report.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	fmt.Println()
+	fmt.Println("Posts a fetch would create :")
+	for _, item := range report.Feed.Channel.Item {
+		if strings.TrimSpace(item.Link) == "" {
+			continue
+		}
+		status := "new"
+		if _, err := s.Db.GetPostByURL(context.Background(), item.Link); err == nil {
+			status = "already stored"
+		}
+		fmt.Printf("  [%s] %s\n         %s\n", status, item.Title, item.Link)
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/azhagan2/blog_aggregator/internal/migrate"
+	"github.com/azhagan2/blog_aggregator/internal/state"
+	"github.com/azhagan2/blog_aggregator/sql/schema"
+)
+
+// HandlerMigrate applies (up), undoes the latest (down) or lists (status) the migrations built into gator
+
+func HandlerMigrate(s *state.State, cmd Clicommand) error {
+
+	if len(cmd.Argument) == 0 {
+		return fmt.Errorf("the handler expects up, down or status")
+	}
+
+	migrations, err := migrate.Load(schema.Migrations, s.Conn.Dialect)
+	if err != nil {
+		return fmt.Errorf("error loading the migrations %w", err)
+	}
+
+	switch cmd.Argument[0] {
+	case "up":
+		done, err := MigrateUp(s)
+		if err != nil {
+			return err
+		}
+		if len(done) == 0 {
+			fmt.Println("Database is up to date")
+		}
+
+	case "down":
+		m, ok, err := migrate.Down(context.Background(), s.Conn, migrations)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("No migrations to undo")
+			return nil
+		}
+		fmt.Println("Undid migration :", m.Name)
+
+	case "status":
+		statuses, err := migrate.StatusOf(context.Background(), s.Conn, migrations)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			if status.Applied {
+				fmt.Printf("%-28s applied %s\n", status.Name, status.AppliedAt.Format("2006-01-02 15:04:05"))
+			} else {
+				fmt.Printf("%-28s pending\n", status.Name)
+			}
+		}
+
+	default:
+		return fmt.Errorf("unknown migrate command %q, use up, down or status", cmd.Argument[0])
+	}
+
+	return nil
+}
+
+// MigrateUp applies the pending migrations, for gator migrate up and for auto_migrate at startup
+
+func MigrateUp(s *state.State) ([]migrate.Migration, error) {
+	migrations, err := migrate.Load(schema.Migrations, s.Conn.Dialect)
+	if err != nil {
+		return nil, fmt.Errorf("error loading the migrations %w", err)
+	}
+
+	done, err := migrate.Up(context.Background(), s.Conn, migrations)
+	for _, m := range done {
+		fmt.Println("Applied migration :", m.Name)
+	}
+	return done, err
+}
@@ -0,0 +1,113 @@
+package command
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/azhagan2/blog_aggregator/internal/database"
+	"github.com/azhagan2/blog_aggregator/internal/state"
+)
+
+/* HandlerMarkRead and HandlerMarkUnread set what counts as read for the logged in user:
+gator markread {post_url}               one post
+gator markread --feed {feed_url}        every post of a feed
+gator markread --before {YYYY-MM-DD}    every post of the followed feeds published before that day
+and markunread the same way. browse only shows unread posts, so markunread brings them back. */
+
+func HandlerMarkRead(s *state.State, cmd Clicommand, user database.User) error {
+	return markPosts(s, cmd, user, true)
+}
+
+func HandlerMarkUnread(s *state.State, cmd Clicommand, user database.User) error {
+	return markPosts(s, cmd, user, false)
+}
+
+func markPosts(s *state.State, cmd Clicommand, user database.User, read bool) error {
+
+	if len(cmd.Argument) == 0 {
+		return fmt.Errorf("the handler expects a post url, --feed {feed_url} or --before {YYYY-MM-DD}")
+	}
+
+	mark := "read"
+	if !read {
+		mark = "unread"
+	}
+
+	ctx := context.Background()
+	userID := uuid.NullUUID{UUID: user.ID, Valid: true}
+
+	switch cmd.Argument[0] {
+	case "--feed":
+		if len(cmd.Argument) < 2 {
+			return fmt.Errorf("--feed needs the feed url")
+		}
+		feed, _, err := feedFor(s, cmd.Argument[1])
+		if err != nil {
+			return fmt.Errorf("error getting feed name %w", err)
+		}
+
+		feedID := uuid.NullUUID{UUID: feed.ID, Valid: true}
+		var n int64
+		if read {
+			n, err = s.Db.MarkFeedRead(ctx, database.MarkFeedReadParams{UserID: user.ID, FeedID: feedID})
+		} else {
+			n, err = s.Db.MarkFeedUnread(ctx, database.MarkFeedUnreadParams{UserID: user.ID, FeedID: feedID})
+		}
+		if err != nil {
+			return fmt.Errorf("error marking the feed %s %w", mark, err)
+		}
+		fmt.Printf("Marked %d posts of %s as %s\n", n, feed.Name, mark)
+
+	case "--before":
+		if len(cmd.Argument) < 2 {
+			return fmt.Errorf("--before needs a date, YYYY-MM-DD")
+		}
+		day, err := time.ParseInLocation("2006-01-02", cmd.Argument[1], time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid date %q, use YYYY-MM-DD", cmd.Argument[1])
+		}
+
+		before := sql.NullTime{Time: day, Valid: true}
+		var n int64
+		if read {
+			n, err = s.Db.MarkReadBefore(ctx, database.MarkReadBeforeParams{UserID: userID, Before: before})
+		} else {
+			n, err = s.Db.MarkUnreadBefore(ctx, database.MarkUnreadBeforeParams{UserID: user.ID, Before: before})
+		}
+		if err != nil {
+			return fmt.Errorf("error marking the posts %s %w", mark, err)
+		}
+		fmt.Printf("Marked %d posts from before %s as %s\n", n, cmd.Argument[1], mark)
+
+	default:
+		post, err := s.Db.GetPostByURL(ctx, cmd.Argument[0])
+		if err != nil {
+			return fmt.Errorf("error getting the post %w", err)
+		}
+		if read {
+			err = markPostRead(s, user, post.ID)
+		} else {
+			err = s.Db.MarkPostUnread(ctx, database.MarkPostUnreadParams{UserID: user.ID, PostID: post.ID})
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Marked %s as %s\n", post.Title, mark)
+	}
+
+	return nil
+}
+
+// markPostRead records that user has read the post, reading it again changes nothing
+
+func markPostRead(s *state.State, user database.User, postID uuid.UUID) error {
+	err := s.Db.MarkPostRead(context.Background(), database.MarkPostReadParams{UserID: user.ID, PostID: postID})
+	if err != nil {
+		return fmt.Errorf("error marking the post read %w", err)
+	}
+	return nil
+}
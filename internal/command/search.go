@@ -0,0 +1,130 @@
+package command
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/azhagan2/blog_aggregator/internal/database"
+	"github.com/azhagan2/blog_aggregator/internal/render"
+	"github.com/azhagan2/blog_aggregator/internal/search"
+	"github.com/azhagan2/blog_aggregator/internal/state"
+)
+
+/* HandlerSearch finds posts by the words in them: gator search [options] {query}
+The query takes "exact phrases", prefix* words, -excluded words and OR. Options:
+--feed {name}         only posts of that feed
+--since {YYYY-MM-DD}  published on or after that day
+--until {YYYY-MM-DD}  published on or before that day
+--following           only feeds you follow
+--limit {n}           how many results, default 10
+Results are ranked, title matches above matches in the text, with the matching words highlighted. */
+
+func HandlerSearch(s *state.State, cmd Clicommand, user database.User) error {
+
+	params, words, err := searchFlags(cmd.Argument)
+	if err != nil {
+		return err
+	}
+
+	query, err := search.Parse(strings.Join(words, " "))
+	if err != nil {
+		return fmt.Errorf("%w, try gator search {query}", err)
+	}
+	params.UserID = uuid.NullUUID{UUID: user.ID, Valid: true}
+
+	// the store writes the query for its database, a tsquery on Postgres and an FTS5 MATCH on SQLite
+	results, err := s.Db.SearchPosts(context.Background(), query, params)
+	if err != nil {
+		return fmt.Errorf("error searching the posts %w", err)
+	}
+
+	fmt.Printf("Found %d posts\n", len(results))
+
+	for _, result := range results {
+		fmt.Println()
+		fmt.Println("Post Name :", result.Title)
+		fmt.Println("Feed Name :", result.FeedName)
+		fmt.Println("Feed URL :", result.Url)
+		if result.PublishedAt.Valid {
+			fmt.Println("Published :", result.PublishedAt.Time.Format("2006-01-02"))
+		}
+		if snippet := highlight(cleanSnippet(result.Snippet)); snippet != "" {
+			fmt.Println(snippet)
+		}
+	}
+
+	return nil
+}
+
+// searchFlags takes the --options out of the arguments, the rest is the query
+
+func searchFlags(args []string) (database.SearchPostsParams, []string, error) {
+	params := database.SearchPostsParams{MaxResults: 10}
+	var words []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "--following":
+			params.FollowedOnly = true
+			continue
+		case "--feed", "--since", "--until", "--limit":
+		default:
+			words = append(words, arg)
+			continue
+		}
+
+		if i+1 >= len(args) {
+			return params, nil, fmt.Errorf("%s needs a value", arg)
+		}
+		i++
+		value := args[i]
+
+		switch arg {
+		case "--feed":
+			params.FeedName = sql.NullString{String: value, Valid: true}
+		case "--since", "--until":
+			day, err := time.ParseInLocation("2006-01-02", value, time.Local)
+			if err != nil {
+				return params, nil, fmt.Errorf("invalid date %q for %s, use YYYY-MM-DD", value, arg)
+			}
+			if arg == "--since" {
+				params.Since = sql.NullTime{Time: day, Valid: true}
+			} else {
+				// the whole day counts
+				params.Until = sql.NullTime{Time: day.AddDate(0, 0, 1), Valid: true}
+			}
+		case "--limit":
+			limit, err := strconv.Atoi(value)
+			if err != nil || limit < 1 {
+				return params, nil, fmt.Errorf("invalid limit %q", value)
+			}
+			params.MaxResults = int32(limit)
+		}
+	}
+	return params, words, nil
+}
+
+var snippetTag = regexp.MustCompile(`<[^>]*>`)
+
+// cleanSnippet makes a snippet plain text, what's left of the markup (SQLite snippets have all of it) and entities
+func cleanSnippet(snippet string) string {
+	snippet = html.UnescapeString(snippetTag.ReplaceAllString(snippet, " "))
+	return strings.Join(strings.Fields(snippet), " ")
+}
+
+// highlight shows the matched words (between « and » from the database) in bold on a terminal
+func highlight(snippet string) string {
+	if !render.IsTerminal() {
+		return snippet
+	}
+	return strings.NewReplacer("«", "\x1b[1m", "»", "\x1b[0m").Replace(snippet)
+}
@@ -0,0 +1,171 @@
+package command
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	rss "github.com/azhagan2/blog_aggregator/internal/RSS"
+	"github.com/azhagan2/blog_aggregator/internal/database"
+	"github.com/azhagan2/blog_aggregator/internal/state"
+	"github.com/azhagan2/blog_aggregator/internal/websub"
+)
+
+/* WebSub lets feeds that advertise a hub push new posts to agg instead of agg polling them. It's off
+unless the config has websub_listen (where agg's callback server listens, e.g. ":8080") and
+websub_callback_url (how the hub reaches that server from the outside, e.g. "https://gator.example.com"). */
+
+func webSubEnabled(s *state.State) bool {
+	return s.Cfg.WebSubListen != "" && s.Cfg.WebSubCallbackURL != ""
+}
+
+func callbackURL(s *state.State, feedID uuid.UUID) string {
+	return strings.TrimRight(s.Cfg.WebSubCallbackURL, "/") + websub.CallbackPath + feedID.String()
+}
+
+// pushedByHub is true while the feed has a verified subscription that isn't about to run out, no need to poll it then
+
+func pushedByHub(s *state.State, feed database.Feed) bool {
+	if !webSubEnabled(s) {
+		return false
+	}
+	sub, err := s.Db.GetWebSubSubscription(context.Background(), feed.ID)
+	if err != nil {
+		return false
+	}
+	return sub.LeaseExpiresAt.Valid && time.Until(sub.LeaseExpiresAt.Time) > websub.RenewBefore
+}
+
+/* subscribeToHub asks the feed's hub (if it has one) to push to us. It runs after every poll, but only
+sends a request when there's no subscription yet or the lease is about to run out, and at most once an
+hour while the hub hasn't verified the last one. A renewal keeps the secret we have, the hub keeps
+signing with the old one until it verifies the new request, and those pushes have to pass too. */
+
+func subscribeToHub(s *state.State, feed database.Feed, result *rss.RSSFeed) error {
+	hub := result.Hub()
+	if !webSubEnabled(s) || hub == "" {
+		return nil
+	}
+
+	topic := result.Self()
+	if topic == "" {
+		topic = feed.Url
+	}
+
+	sub, err := s.Db.GetWebSubSubscription(context.Background(), feed.ID)
+	secret := sub.Secret
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		secret, err = websub.NewSecret()
+		if err != nil {
+			return err
+		}
+	case err != nil:
+		return fmt.Errorf("error getting the websub subscription %w", err)
+	case sub.LeaseExpiresAt.Valid && time.Until(sub.LeaseExpiresAt.Time) > websub.RenewBefore:
+		return nil
+	case time.Since(sub.UpdatedAt) < time.Hour:
+		// asked (or verified) less than an hour ago
+		return nil
+	}
+
+	// save before asking, the hub may call back before Subscribe even returns
+	_, err = s.Db.UpsertWebSubSubscription(context.Background(), database.UpsertWebSubSubscriptionParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		FeedID:    feed.ID,
+		HubUrl:    hub,
+		TopicUrl:  topic,
+		Secret:    secret,
+	})
+	if err != nil {
+		return fmt.Errorf("error saving the websub subscription %w", err)
+	}
+
+	if err := websub.Subscribe(context.Background(), hub, topic, callbackURL(s, feed.ID), secret); err != nil {
+		return err
+	}
+
+	fmt.Println("Asked the WebSub hub to push", feed.Name)
+	return nil
+}
+
+// serveWebSub runs the callback server next to the agg loop, hubs verify subscriptions and push posts here
+
+func serveWebSub(s *state.State) {
+	callback := &websub.Callback{
+		Lookup: func(ctx context.Context, id string) (websub.Subscription, bool) {
+			feedID, err := uuid.Parse(id)
+			if err != nil {
+				return websub.Subscription{}, false
+			}
+			sub, err := s.Db.GetWebSubSubscription(ctx, feedID)
+			if err != nil {
+				return websub.Subscription{}, false
+			}
+			return websub.Subscription{Topic: sub.TopicUrl, Secret: sub.Secret}, true
+		},
+
+		Verified: func(ctx context.Context, id, mode string, lease time.Duration) error {
+			feedID, err := uuid.Parse(id)
+			if err != nil {
+				return err
+			}
+			if mode == "denied" {
+				fmt.Println("WebSub hub denied the subscription for feed", id)
+				if err := s.Db.DeleteWebSubSubscription(ctx, feedID); err != nil {
+					fmt.Println("Couldn't delete the denied WebSub subscription:", err)
+					return err
+				}
+				return nil
+			}
+			if lease <= 0 {
+				lease = websub.Lease
+			}
+			err = s.Db.SetWebSubLease(ctx, database.SetWebSubLeaseParams{
+				FeedID:         feedID,
+				LeaseExpiresAt: sql.NullTime{Time: time.Now().Add(lease), Valid: true},
+			})
+			if err != nil {
+				fmt.Println("Couldn't save the WebSub lease for feed", id, err)
+				return err
+			}
+			fmt.Println("WebSub subscription verified for feed", id)
+			return nil
+		},
+
+		Deliver: func(ctx context.Context, id string, body []byte) error {
+			feedID, err := uuid.Parse(id)
+			if err != nil {
+				return err
+			}
+			feed, err := s.Db.GetFeedByID(ctx, feedID)
+			if err != nil {
+				return fmt.Errorf("error getting the pushed feed %w", err)
+			}
+			pushed, err := rss.ParseFeed(body)
+			if err != nil {
+				return err
+			}
+			fmt.Println("WebSub push for", feed.Name)
+			posts, err := createPosts(s.Db, feed, pushed.Channel.Item)
+			if err != nil {
+				return err
+			}
+			fetchFullTexts(s, feed, posts)
+			return nil
+		},
+	}
+
+	fmt.Println("WebSub callback server listening on", s.Cfg.WebSubListen)
+	if err := http.ListenAndServe(s.Cfg.WebSubListen, callback.Handler()); err != nil {
+		fmt.Println("WebSub callback server stopped:", err)
+	}
+}
@@ -11,7 +11,7 @@ import (
 
 const get_Next_Feed_to_fetch = `-- name: Get_Next_Feed_to_fetch :one
 
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at
+SELECT created_at, updated_at, name, url, last_fetched_at, fetch_full_text, kind, item_selector, title_selector, link_selector, date_selector, summary_selector, credentials, image_url, favicon_path, title, description, site_url, language, id, user_id
 FROM feeds 
 ORDER BY last_fetched_at NULLS FIRST
 LIMIT 1
@@ -21,13 +21,27 @@ func (q *Queries) Get_Next_Feed_to_fetch(ctx context.Context) (Feed, error) {
 	row := q.db.QueryRowContext(ctx, get_Next_Feed_to_fetch)
 	var i Feed
 	err := row.Scan(
-		&i.ID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
 		&i.Url,
-		&i.UserID,
 		&i.LastFetchedAt,
+		&i.FetchFullText,
+		&i.Kind,
+		&i.ItemSelector,
+		&i.TitleSelector,
+		&i.LinkSelector,
+		&i.DateSelector,
+		&i.SummarySelector,
+		&i.Credentials,
+		&i.ImageUrl,
+		&i.FaviconPath,
+		&i.Title,
+		&i.Description,
+		&i.SiteUrl,
+		&i.Language,
+		&i.ID,
+		&i.UserID,
 	)
 	return i, err
 }
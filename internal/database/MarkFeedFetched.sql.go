@@ -7,6 +7,8 @@ package database
 
 import (
 	"context"
+
+	"github.com/google/uuid"
 )
 
 const mark_Feed_Fetched = `-- name: Mark_Feed_Fetched :exec
@@ -17,7 +19,7 @@ SET updated_at = NOW(),
 WHERE id = $1
 `
 
-func (q *Queries) Mark_Feed_Fetched(ctx context.Context, id int32) error {
+func (q *Queries) Mark_Feed_Fetched(ctx context.Context, id uuid.UUID) error {
 	_, err := q.db.ExecContext(ctx, mark_Feed_Fetched, id)
 	return err
 }
@@ -0,0 +1,105 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: archive.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const getPostByURL = `-- name: GetPostByURL :one
+SELECT created_at, updated_at, title, url, description, published_at, content, archive_path, archived_at, id, feed_id
+FROM posts
+WHERE url = $1
+`
+
+func (q *Queries) GetPostByURL(ctx context.Context, url string) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPostByURL, url)
+	var i Post
+	err := row.Scan(
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.Content,
+		&i.ArchivePath,
+		&i.ArchivedAt,
+		&i.ID,
+		&i.FeedID,
+	)
+	return i, err
+}
+
+const getPostsToArchive = `-- name: GetPostsToArchive :many
+SELECT posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.content, posts.archive_path, posts.archived_at, posts.id, posts.feed_id
+FROM posts
+JOIN feed_follows a ON posts.feed_id = a.feed_id
+WHERE a.user_id = $1 AND posts.archive_path IS NULL
+ORDER BY posts.published_at DESC
+LIMIT $2
+`
+
+type GetPostsToArchiveParams struct {
+	UserID uuid.NullUUID
+	Limit  int32
+}
+
+func (q *Queries) GetPostsToArchive(ctx context.Context, arg GetPostsToArchiveParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsToArchive, arg.UserID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.Content,
+			&i.ArchivePath,
+			&i.ArchivedAt,
+			&i.ID,
+			&i.FeedID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setPostArchive = `-- name: SetPostArchive :exec
+UPDATE posts
+SET archive_path = $2,
+    archived_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1
+`
+
+type SetPostArchiveParams struct {
+	ID          uuid.UUID
+	ArchivePath sql.NullString
+}
+
+func (q *Queries) SetPostArchive(ctx context.Context, arg SetPostArchiveParams) error {
+	_, err := q.db.ExecContext(ctx, setPostArchive, arg.ID, arg.ArchivePath)
+	return err
+}
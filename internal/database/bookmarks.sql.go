@@ -0,0 +1,190 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: bookmarks.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const addBookmarkTag = `-- name: AddBookmarkTag :exec
+INSERT INTO bookmark_tags (bookmark_id, tag)
+VALUES ($1, $2)
+ON CONFLICT (bookmark_id, tag) DO NOTHING
+`
+
+type AddBookmarkTagParams struct {
+	BookmarkID uuid.UUID
+	Tag        string
+}
+
+func (q *Queries) AddBookmarkTag(ctx context.Context, arg AddBookmarkTagParams) error {
+	_, err := q.db.ExecContext(ctx, addBookmarkTag, arg.BookmarkID, arg.Tag)
+	return err
+}
+
+const deleteBookmark = `-- name: DeleteBookmark :execrows
+DELETE FROM bookmarks
+WHERE user_id = $1 AND url = $2
+`
+
+type DeleteBookmarkParams struct {
+	UserID uuid.UUID
+	Url    string
+}
+
+func (q *Queries) DeleteBookmark(ctx context.Context, arg DeleteBookmarkParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteBookmark, arg.UserID, arg.Url)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getBookmarkTags = `-- name: GetBookmarkTags :many
+SELECT t.bookmark_id, t.tag
+FROM bookmark_tags t
+JOIN bookmarks ON bookmarks.id = t.bookmark_id
+WHERE bookmarks.user_id = $1
+ORDER BY t.tag
+`
+
+func (q *Queries) GetBookmarkTags(ctx context.Context, userID uuid.UUID) ([]BookmarkTag, error) {
+	rows, err := q.db.QueryContext(ctx, getBookmarkTags, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BookmarkTag
+	for rows.Next() {
+		var i BookmarkTag
+		if err := rows.Scan(&i.BookmarkID, &i.Tag); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBookmarks = `-- name: GetBookmarks :many
+SELECT id, created_at, updated_at, user_id, post_id, url, title, description, published_at, feed_name, note
+FROM bookmarks
+WHERE user_id = $1
+    AND (CAST($2 AS TEXT) IS NULL OR EXISTS (
+        SELECT 1 FROM bookmark_tags t WHERE t.bookmark_id = bookmarks.id AND t.tag = $2
+    ))
+ORDER BY created_at DESC
+`
+
+type GetBookmarksParams struct {
+	UserID uuid.UUID
+	Tag    sql.NullString
+}
+
+// newest saved first, only the ones with the tag when one is given
+func (q *Queries) GetBookmarks(ctx context.Context, arg GetBookmarksParams) ([]Bookmark, error) {
+	rows, err := q.db.QueryContext(ctx, getBookmarks, arg.UserID, arg.Tag)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Bookmark
+	for rows.Next() {
+		var i Bookmark
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.PostID,
+			&i.Url,
+			&i.Title,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedName,
+			&i.Note,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertBookmark = `-- name: UpsertBookmark :one
+INSERT INTO bookmarks (id, created_at, updated_at, user_id, post_id, url, title, description, published_at, feed_name, note)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+ON CONFLICT (user_id, url) DO UPDATE
+SET updated_at = excluded.updated_at,
+    post_id = excluded.post_id,
+    title = excluded.title,
+    description = excluded.description,
+    published_at = excluded.published_at,
+    feed_name = excluded.feed_name,
+    note = coalesce(excluded.note, bookmarks.note)
+RETURNING id, created_at, updated_at, user_id, post_id, url, title, description, published_at, feed_name, note
+`
+
+type UpsertBookmarkParams struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uuid.UUID
+	PostID      uuid.NullUUID
+	Url         string
+	Title       string
+	Description string
+	PublishedAt sql.NullTime
+	FeedName    sql.NullString
+	Note        sql.NullString
+}
+
+// saving a post again refreshes the copy, and the note when a new one is given
+func (q *Queries) UpsertBookmark(ctx context.Context, arg UpsertBookmarkParams) (Bookmark, error) {
+	row := q.db.QueryRowContext(ctx, upsertBookmark,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.UserID,
+		arg.PostID,
+		arg.Url,
+		arg.Title,
+		arg.Description,
+		arg.PublishedAt,
+		arg.FeedName,
+		arg.Note,
+	)
+	var i Bookmark
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.PostID,
+		&i.Url,
+		&i.Title,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedName,
+		&i.Note,
+	)
+	return i, err
+}
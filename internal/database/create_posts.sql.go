@@ -9,6 +9,8 @@ import (
 	"context"
 	"database/sql"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 const createPost = `-- name: CreatePost :one
@@ -23,18 +25,18 @@ VALUES (
     $7,
     $8
 )
-RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id
+RETURNING created_at, updated_at, title, url, description, published_at, content, archive_path, archived_at, id, feed_id
 `
 
 type CreatePostParams struct {
-	ID          int32
+	ID          uuid.UUID
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 	Title       string
 	Url         string
 	Description string
 	PublishedAt sql.NullTime
-	FeedID      sql.NullInt32
+	FeedID      uuid.NullUUID
 }
 
 func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
@@ -50,13 +52,16 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 	)
 	var i Post
 	err := row.Scan(
-		&i.ID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Title,
 		&i.Url,
 		&i.Description,
 		&i.PublishedAt,
+		&i.Content,
+		&i.ArchivePath,
+		&i.ArchivedAt,
+		&i.ID,
 		&i.FeedID,
 	)
 	return i, err
@@ -7,7 +7,8 @@ package database
 
 import (
 	"context"
-	"database/sql"
+
+	"github.com/google/uuid"
 )
 
 const delete_Feed_Follow = `-- name: Delete_Feed_Follow :exec
@@ -17,8 +18,8 @@ WHERE a.user_id = $1 and a.feed_id = $2
 `
 
 type Delete_Feed_FollowParams struct {
-	UserID sql.NullInt32
-	FeedID sql.NullInt32
+	UserID uuid.NullUUID
+	FeedID uuid.NullUUID
 }
 
 func (q *Queries) Delete_Feed_Follow(ctx context.Context, arg Delete_Feed_FollowParams) error {
@@ -0,0 +1,30 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: feed_credentials.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const setFeedCredentials = `-- name: SetFeedCredentials :exec
+UPDATE feeds
+SET credentials = $2,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+type SetFeedCredentialsParams struct {
+	ID          uuid.UUID
+	Credentials sql.NullString
+}
+
+func (q *Queries) SetFeedCredentials(ctx context.Context, arg SetFeedCredentialsParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedCredentials, arg.ID, arg.Credentials)
+	return err
+}
@@ -9,41 +9,39 @@ import (
 	"context"
 	"database/sql"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 const createFeedFollow = `-- name: CreateFeedFollow :one
-WITH inserted_feed_follow AS (
-    INSERT INTO feed_follows(id, created_at, updated_at, user_id, feed_id)
-    VALUES(
-        $1,
-        $2,
-        $3,
-        $4,
-        $5
-    )
-    RETURNING id, created_at, updated_at, user_id, feed_id
+INSERT INTO feed_follows(id, created_at, updated_at, user_id, feed_id)
+VALUES(
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
 )
-
-SELECT a.id, a.created_at, a.updated_at, a.user_id, a.feed_id, feeds.name AS feed_name, users.name AS user_name
-FROM inserted_feed_follow a 
-INNER JOIN users on users.id = a.user_id
-INNER JOIN feeds on feeds.id = a.feed_id
+RETURNING created_at, updated_at, id, user_id, feed_id, folder,
+    (SELECT feeds.name FROM feeds WHERE feeds.id = feed_follows.feed_id) AS feed_name,
+    (SELECT users.name FROM users WHERE users.id = feed_follows.user_id) AS user_name
 `
 
 type CreateFeedFollowParams struct {
-	ID        int32
+	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	UserID    sql.NullInt32
-	FeedID    sql.NullInt32
+	UserID    uuid.NullUUID
+	FeedID    uuid.NullUUID
 }
 
 type CreateFeedFollowRow struct {
-	ID        int32
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	UserID    sql.NullInt32
-	FeedID    sql.NullInt32
+	ID        uuid.UUID
+	UserID    uuid.NullUUID
+	FeedID    uuid.NullUUID
+	Folder    sql.NullString
 	FeedName  string
 	UserName  string
 }
@@ -58,11 +56,12 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 	)
 	var i CreateFeedFollowRow
 	err := row.Scan(
-		&i.ID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ID,
 		&i.UserID,
 		&i.FeedID,
+		&i.Folder,
 		&i.FeedName,
 		&i.UserName,
 	)
@@ -0,0 +1,41 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: feed_images.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const setFeedFavicon = `-- name: SetFeedFavicon :exec
+UPDATE feeds SET favicon_path = $2, updated_at = NOW() WHERE id = $1
+`
+
+type SetFeedFaviconParams struct {
+	ID          uuid.UUID
+	FaviconPath sql.NullString
+}
+
+func (q *Queries) SetFeedFavicon(ctx context.Context, arg SetFeedFaviconParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFavicon, arg.ID, arg.FaviconPath)
+	return err
+}
+
+const setFeedImage = `-- name: SetFeedImage :exec
+UPDATE feeds SET image_url = $2, updated_at = NOW() WHERE id = $1
+`
+
+type SetFeedImageParams struct {
+	ID       uuid.UUID
+	ImageUrl sql.NullString
+}
+
+func (q *Queries) SetFeedImage(ctx context.Context, arg SetFeedImageParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedImage, arg.ID, arg.ImageUrl)
+	return err
+}
@@ -0,0 +1,41 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: feed_metadata.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const setFeedMetadata = `-- name: SetFeedMetadata :exec
+UPDATE feeds
+SET title = $2,
+    description = $3,
+    site_url = $4,
+    language = $5
+WHERE id = $1
+`
+
+type SetFeedMetadataParams struct {
+	ID          uuid.UUID
+	Title       sql.NullString
+	Description sql.NullString
+	SiteUrl     sql.NullString
+	Language    sql.NullString
+}
+
+func (q *Queries) SetFeedMetadata(ctx context.Context, arg SetFeedMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedMetadata,
+		arg.ID,
+		arg.Title,
+		arg.Description,
+		arg.SiteUrl,
+		arg.Language,
+	)
+	return err
+}
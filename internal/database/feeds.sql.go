@@ -7,8 +7,9 @@ package database
 
 import (
 	"context"
-	"database/sql"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 const createFeed = `-- name: CreateFeed :one
@@ -21,16 +22,16 @@ VALUES (
     $5,
     $6
 )
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING created_at, updated_at, name, url, last_fetched_at, fetch_full_text, kind, item_selector, title_selector, link_selector, date_selector, summary_selector, credentials, image_url, favicon_path, title, description, site_url, language, id, user_id
 `
 
 type CreateFeedParams struct {
-	ID        int32
+	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	Name      string
 	Url       string
-	UserID    sql.NullInt32
+	UserID    uuid.NullUUID
 }
 
 func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error) {
@@ -44,13 +45,79 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 	)
 	var i Feed
 	err := row.Scan(
-		&i.ID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
 		&i.Url,
+		&i.LastFetchedAt,
+		&i.FetchFullText,
+		&i.Kind,
+		&i.ItemSelector,
+		&i.TitleSelector,
+		&i.LinkSelector,
+		&i.DateSelector,
+		&i.SummarySelector,
+		&i.Credentials,
+		&i.ImageUrl,
+		&i.FaviconPath,
+		&i.Title,
+		&i.Description,
+		&i.SiteUrl,
+		&i.Language,
+		&i.ID,
 		&i.UserID,
+	)
+	return i, err
+}
+
+const getFeedByName = `-- name: GetFeedByName :one
+SELECT created_at, updated_at, name, url, last_fetched_at, fetch_full_text, kind, item_selector, title_selector, link_selector, date_selector, summary_selector, credentials, image_url, favicon_path, title, description, site_url, language, id, user_id
+FROM feeds
+WHERE name = $1
+`
+
+func (q *Queries) GetFeedByName(ctx context.Context, name string) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByName, name)
+	var i Feed
+	err := row.Scan(
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
 		&i.LastFetchedAt,
+		&i.FetchFullText,
+		&i.Kind,
+		&i.ItemSelector,
+		&i.TitleSelector,
+		&i.LinkSelector,
+		&i.DateSelector,
+		&i.SummarySelector,
+		&i.Credentials,
+		&i.ImageUrl,
+		&i.FaviconPath,
+		&i.Title,
+		&i.Description,
+		&i.SiteUrl,
+		&i.Language,
+		&i.ID,
+		&i.UserID,
 	)
 	return i, err
 }
+
+const setFeedKind = `-- name: SetFeedKind :exec
+UPDATE feeds
+SET kind = $2,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+type SetFeedKindParams struct {
+	ID   uuid.UUID
+	Kind string
+}
+
+func (q *Queries) SetFeedKind(ctx context.Context, arg SetFeedKindParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedKind, arg.ID, arg.Kind)
+	return err
+}
@@ -0,0 +1,47 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: full_text.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const setFeedFullText = `-- name: SetFeedFullText :exec
+UPDATE feeds
+SET fetch_full_text = $2,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+type SetFeedFullTextParams struct {
+	ID            uuid.UUID
+	FetchFullText bool
+}
+
+func (q *Queries) SetFeedFullText(ctx context.Context, arg SetFeedFullTextParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFullText, arg.ID, arg.FetchFullText)
+	return err
+}
+
+const setPostContent = `-- name: SetPostContent :exec
+UPDATE posts
+SET content = $2,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+type SetPostContentParams struct {
+	ID      uuid.UUID
+	Content sql.NullString
+}
+
+func (q *Queries) SetPostContent(ctx context.Context, arg SetPostContentParams) error {
+	_, err := q.db.ExecContext(ctx, setPostContent, arg.ID, arg.Content)
+	return err
+}
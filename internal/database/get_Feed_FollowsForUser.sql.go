@@ -7,29 +7,62 @@ package database
 
 import (
 	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
 )
 
 const getFeedFollowsForUser = `-- name: GetFeedFollowsForUser :many
-SELECT feeds.name
+SELECT feeds.name, feeds.url, feeds.title, feeds.site_url, feeds.description, a.folder, (
+    SELECT count(*) FROM posts
+    WHERE posts.feed_id = feeds.id AND NOT EXISTS (
+        SELECT 1 FROM post_reads r WHERE r.post_id = posts.id AND r.user_id = users.id
+    )
+) AS unread
 FROM feed_follows a 
 INNER JOIN users on users.id = a.user_id
 INNER JOIN feeds on feeds.id = a.feed_id
 WHERE users.name = $1
+    AND (CAST($2 AS TEXT) IS NULL OR lower(a.folder) = lower($2))
+ORDER BY coalesce(a.folder, ''), feeds.name
 `
 
-func (q *Queries) GetFeedFollowsForUser(ctx context.Context, name string) ([]string, error) {
-	rows, err := q.db.QueryContext(ctx, getFeedFollowsForUser, name)
+type GetFeedFollowsForUserParams struct {
+	Name   string
+	Folder sql.NullString
+}
+
+type GetFeedFollowsForUserRow struct {
+	Name        string
+	Url         string
+	Title       sql.NullString
+	SiteUrl     sql.NullString
+	Description sql.NullString
+	Folder      sql.NullString
+	Unread      int64
+}
+
+func (q *Queries) GetFeedFollowsForUser(ctx context.Context, arg GetFeedFollowsForUserParams) ([]GetFeedFollowsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedFollowsForUser, arg.Name, arg.Folder)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []string
+	var items []GetFeedFollowsForUserRow
 	for rows.Next() {
-		var name string
-		if err := rows.Scan(&name); err != nil {
+		var i GetFeedFollowsForUserRow
+		if err := rows.Scan(
+			&i.Name,
+			&i.Url,
+			&i.Title,
+			&i.SiteUrl,
+			&i.Description,
+			&i.Folder,
+			&i.Unread,
+		); err != nil {
 			return nil, err
 		}
-		items = append(items, name)
+		items = append(items, i)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -39,3 +72,24 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, name string) ([]str
 	}
 	return items, nil
 }
+
+const setFollowFolder = `-- name: SetFollowFolder :execrows
+UPDATE feed_follows
+SET folder = $1,
+    updated_at = now()
+WHERE user_id = $2 AND feed_id = $3
+`
+
+type SetFollowFolderParams struct {
+	Folder sql.NullString
+	UserID uuid.NullUUID
+	FeedID uuid.NullUUID
+}
+
+func (q *Queries) SetFollowFolder(ctx context.Context, arg SetFollowFolderParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setFollowFolder, arg.Folder, arg.UserID, arg.FeedID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -10,7 +10,7 @@ import (
 )
 
 const getFeed_ByURL = `-- name: GetFeed_ByURL :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at
+SELECT created_at, updated_at, name, url, last_fetched_at, fetch_full_text, kind, item_selector, title_selector, link_selector, date_selector, summary_selector, credentials, image_url, favicon_path, title, description, site_url, language, id, user_id
 FROM feeds 
 WHERE url = $1
 `
@@ -19,13 +19,27 @@ func (q *Queries) GetFeed_ByURL(ctx context.Context, url string) (Feed, error) {
 	row := q.db.QueryRowContext(ctx, getFeed_ByURL, url)
 	var i Feed
 	err := row.Scan(
-		&i.ID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
 		&i.Url,
-		&i.UserID,
 		&i.LastFetchedAt,
+		&i.FetchFullText,
+		&i.Kind,
+		&i.ItemSelector,
+		&i.TitleSelector,
+		&i.LinkSelector,
+		&i.DateSelector,
+		&i.SummarySelector,
+		&i.Credentials,
+		&i.ImageUrl,
+		&i.FaviconPath,
+		&i.Title,
+		&i.Description,
+		&i.SiteUrl,
+		&i.Language,
+		&i.ID,
+		&i.UserID,
 	)
 	return i, err
 }
@@ -8,49 +8,54 @@ package database
 import (
 	"context"
 	"database/sql"
-	"time"
+
+	"github.com/google/uuid"
 )
 
 const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT posts.id, posts.created_at, posts.updated_at, title, posts.url, description, published_at, posts.feed_id, a.id, a.created_at, a.updated_at, a.user_id, a.feed_id, b.id, b.created_at, b.updated_at, name, b.url, b.user_id, last_fetched_at
+SELECT posts.id, posts.title, posts.url, posts.description, posts.content, posts.published_at, posts.archive_path,
+    b.name AS feed_name, a.folder, EXISTS (
+    SELECT 1 FROM post_reads r WHERE r.post_id = posts.id AND r.user_id = a.user_id
+) AS is_read
 FROM posts 
 JOIN feed_follows a ON posts.feed_id = a.feed_id  
 JOIN feeds b ON a.feed_id = b.id
 WHERE a.user_id = $1
+    AND (NOT CAST($2 AS BOOLEAN) OR NOT EXISTS (
+        SELECT 1 FROM post_reads r WHERE r.post_id = posts.id AND r.user_id = a.user_id
+    ))
+    AND (CAST($3 AS TEXT) IS NULL OR lower(a.folder) = lower($3))
 ORDER BY posts.published_at DESC
-LIMIT $2
+LIMIT $4
 `
 
 type GetPostsForUserParams struct {
-	UserID sql.NullInt32
-	Limit  int32
+	UserID     uuid.NullUUID
+	UnreadOnly bool
+	Folder     sql.NullString
+	Limit      int32
 }
 
 type GetPostsForUserRow struct {
-	ID            int32
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Title         string
-	Url           string
-	Description   string
-	PublishedAt   sql.NullTime
-	FeedID        sql.NullInt32
-	ID_2          int32
-	CreatedAt_2   time.Time
-	UpdatedAt_2   time.Time
-	UserID        sql.NullInt32
-	FeedID_2      sql.NullInt32
-	ID_3          int32
-	CreatedAt_3   time.Time
-	UpdatedAt_3   time.Time
-	Name          string
-	Url_2         string
-	UserID_2      sql.NullInt32
-	LastFetchedAt sql.NullTime
+	ID          uuid.UUID
+	Title       string
+	Url         string
+	Description string
+	Content     sql.NullString
+	PublishedAt sql.NullTime
+	ArchivePath sql.NullString
+	FeedName    string
+	Folder      sql.NullString
+	IsRead      bool
 }
 
 func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, getPostsForUser,
+		arg.UserID,
+		arg.UnreadOnly,
+		arg.Folder,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -60,25 +65,15 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 		var i GetPostsForUserRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
 			&i.Title,
 			&i.Url,
 			&i.Description,
+			&i.Content,
 			&i.PublishedAt,
-			&i.FeedID,
-			&i.ID_2,
-			&i.CreatedAt_2,
-			&i.UpdatedAt_2,
-			&i.UserID,
-			&i.FeedID_2,
-			&i.ID_3,
-			&i.CreatedAt_3,
-			&i.UpdatedAt_3,
-			&i.Name,
-			&i.Url_2,
-			&i.UserID_2,
-			&i.LastFetchedAt,
+			&i.ArchivePath,
+			&i.FeedName,
+			&i.Folder,
+			&i.IsRead,
 		); err != nil {
 			return nil, err
 		}
@@ -7,6 +7,8 @@ package database
 
 import (
 	"context"
+
+	"github.com/google/uuid"
 )
 
 const getUserById = `-- name: GetUserById :one
@@ -15,7 +17,7 @@ FROM users
 WHERE id = $1
 `
 
-func (q *Queries) GetUserById(ctx context.Context, id int32) (string, error) {
+func (q *Queries) GetUserById(ctx context.Context, id uuid.UUID) (string, error) {
 	row := q.db.QueryRowContext(ctx, getUserById, id)
 	var name string
 	err := row.Scan(&name)
@@ -0,0 +1,46 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: html_feeds.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const setFeedSelectors = `-- name: SetFeedSelectors :exec
+UPDATE feeds
+SET kind = 'html',
+    item_selector = $2,
+    title_selector = $3,
+    link_selector = $4,
+    date_selector = $5,
+    summary_selector = $6,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+type SetFeedSelectorsParams struct {
+	ID              uuid.UUID
+	ItemSelector    sql.NullString
+	TitleSelector   sql.NullString
+	LinkSelector    sql.NullString
+	DateSelector    sql.NullString
+	SummarySelector sql.NullString
+}
+
+func (q *Queries) SetFeedSelectors(ctx context.Context, arg SetFeedSelectorsParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedSelectors,
+		arg.ID,
+		arg.ItemSelector,
+		arg.TitleSelector,
+		arg.LinkSelector,
+		arg.DateSelector,
+		arg.SummarySelector,
+	)
+	return err
+}
@@ -7,40 +7,96 @@ package database
 import (
 	"database/sql"
 	"time"
+
+	"github.com/google/uuid"
 )
 
+type Bookmark struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uuid.UUID
+	PostID      uuid.NullUUID
+	Url         string
+	Title       string
+	Description string
+	PublishedAt sql.NullTime
+	FeedName    sql.NullString
+	Note        sql.NullString
+}
+
+type BookmarkTag struct {
+	BookmarkID uuid.UUID
+	Tag        string
+}
+
 type Feed struct {
-	ID            int32
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	Url           string
-	UserID        sql.NullInt32
-	LastFetchedAt sql.NullTime
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Name            string
+	Url             string
+	LastFetchedAt   sql.NullTime
+	FetchFullText   bool
+	Kind            string
+	ItemSelector    sql.NullString
+	TitleSelector   sql.NullString
+	LinkSelector    sql.NullString
+	DateSelector    sql.NullString
+	SummarySelector sql.NullString
+	Credentials     sql.NullString
+	ImageUrl        sql.NullString
+	FaviconPath     sql.NullString
+	Title           sql.NullString
+	Description     sql.NullString
+	SiteUrl         sql.NullString
+	Language        sql.NullString
+	ID              uuid.UUID
+	UserID          uuid.NullUUID
 }
 
 type FeedFollow struct {
-	ID        int32
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	UserID    sql.NullInt32
-	FeedID    sql.NullInt32
+	ID        uuid.UUID
+	UserID    uuid.NullUUID
+	FeedID    uuid.NullUUID
+	Folder    sql.NullString
 }
 
 type Post struct {
-	ID          int32
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 	Title       string
 	Url         string
 	Description string
 	PublishedAt sql.NullTime
-	FeedID      sql.NullInt32
+	Content     sql.NullString
+	ArchivePath sql.NullString
+	ArchivedAt  sql.NullTime
+	ID          uuid.UUID
+	FeedID      uuid.NullUUID
+}
+
+type PostRead struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+	ReadAt time.Time
 }
 
 type User struct {
-	ID        int32
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	Name      string
+	ID        uuid.UUID
+}
+
+type WebsubSubscription struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	FeedID         uuid.UUID
+	HubUrl         string
+	TopicUrl       string
+	Secret         string
+	LeaseExpiresAt sql.NullTime
 }
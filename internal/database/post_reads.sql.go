@@ -0,0 +1,126 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: post_reads.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const markFeedRead = `-- name: MarkFeedRead :execrows
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT $1, posts.id, NOW()
+FROM posts
+WHERE posts.feed_id = $2
+ON CONFLICT (user_id, post_id) DO NOTHING
+`
+
+type MarkFeedReadParams struct {
+	UserID uuid.UUID
+	FeedID uuid.NullUUID
+}
+
+func (q *Queries) MarkFeedRead(ctx context.Context, arg MarkFeedReadParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markFeedRead, arg.UserID, arg.FeedID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const markFeedUnread = `-- name: MarkFeedUnread :execrows
+DELETE FROM post_reads
+WHERE user_id = $1 AND post_id IN (SELECT posts.id FROM posts WHERE posts.feed_id = $2)
+`
+
+type MarkFeedUnreadParams struct {
+	UserID uuid.UUID
+	FeedID uuid.NullUUID
+}
+
+func (q *Queries) MarkFeedUnread(ctx context.Context, arg MarkFeedUnreadParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markFeedUnread, arg.UserID, arg.FeedID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const markPostRead = `-- name: MarkPostRead :exec
+INSERT INTO post_reads (user_id, post_id, read_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (user_id, post_id) DO NOTHING
+`
+
+type MarkPostReadParams struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+}
+
+func (q *Queries) MarkPostRead(ctx context.Context, arg MarkPostReadParams) error {
+	_, err := q.db.ExecContext(ctx, markPostRead, arg.UserID, arg.PostID)
+	return err
+}
+
+const markPostUnread = `-- name: MarkPostUnread :exec
+DELETE FROM post_reads
+WHERE user_id = $1 AND post_id = $2
+`
+
+type MarkPostUnreadParams struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+}
+
+func (q *Queries) MarkPostUnread(ctx context.Context, arg MarkPostUnreadParams) error {
+	_, err := q.db.ExecContext(ctx, markPostUnread, arg.UserID, arg.PostID)
+	return err
+}
+
+const markReadBefore = `-- name: MarkReadBefore :execrows
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT a.user_id, posts.id, NOW()
+FROM posts
+JOIN feed_follows a ON a.feed_id = posts.feed_id
+WHERE a.user_id = $1 AND coalesce(posts.published_at, posts.created_at) < $2
+ON CONFLICT (user_id, post_id) DO NOTHING
+`
+
+type MarkReadBeforeParams struct {
+	UserID uuid.NullUUID
+	Before sql.NullTime
+}
+
+// the posts of every followed feed published before the date, or stored before it when they have no date
+func (q *Queries) MarkReadBefore(ctx context.Context, arg MarkReadBeforeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markReadBefore, arg.UserID, arg.Before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const markUnreadBefore = `-- name: MarkUnreadBefore :execrows
+DELETE FROM post_reads
+WHERE user_id = $1 AND post_id IN (
+    SELECT posts.id FROM posts WHERE coalesce(posts.published_at, posts.created_at) < $2
+)
+`
+
+type MarkUnreadBeforeParams struct {
+	UserID uuid.UUID
+	Before sql.NullTime
+}
+
+func (q *Queries) MarkUnreadBefore(ctx context.Context, arg MarkUnreadBeforeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markUnreadBefore, arg.UserID, arg.Before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,66 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type Querier interface {
+	AddBookmarkTag(ctx context.Context, arg AddBookmarkTagParams) error
+	CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error)
+	CreateFeedFollow(ctx context.Context, arg CreateFeedFollowParams) (CreateFeedFollowRow, error)
+	CreatePost(ctx context.Context, arg CreatePostParams) (Post, error)
+	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	DeleteBookmark(ctx context.Context, arg DeleteBookmarkParams) (int64, error)
+	DeleteUser(ctx context.Context) error
+	DeleteWebSubSubscription(ctx context.Context, feedID uuid.UUID) error
+	Delete_Feed_Follow(ctx context.Context, arg Delete_Feed_FollowParams) error
+	GetBookmarkTags(ctx context.Context, userID uuid.UUID) ([]BookmarkTag, error)
+	// newest saved first, only the ones with the tag when one is given
+	GetBookmarks(ctx context.Context, arg GetBookmarksParams) ([]Bookmark, error)
+	GetFeedByID(ctx context.Context, id uuid.UUID) (Feed, error)
+	GetFeedByName(ctx context.Context, name string) (Feed, error)
+	GetFeedFollowsForUser(ctx context.Context, arg GetFeedFollowsForUserParams) ([]GetFeedFollowsForUserRow, error)
+	GetFeed_ByURL(ctx context.Context, url string) (Feed, error)
+	GetFeeds(ctx context.Context) ([]Feed, error)
+	GetPostByURL(ctx context.Context, url string) (Post, error)
+	GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error)
+	GetPostsToArchive(ctx context.Context, arg GetPostsToArchiveParams) ([]Post, error)
+	GetUser(ctx context.Context, name string) (User, error)
+	GetUserById(ctx context.Context, id uuid.UUID) (string, error)
+	GetUsers(ctx context.Context) ([]string, error)
+	GetWebSubSubscription(ctx context.Context, feedID uuid.UUID) (WebsubSubscription, error)
+	Get_Next_Feed_to_fetch(ctx context.Context) (Feed, error)
+	MarkFeedRead(ctx context.Context, arg MarkFeedReadParams) (int64, error)
+	MarkFeedUnread(ctx context.Context, arg MarkFeedUnreadParams) (int64, error)
+	MarkPostRead(ctx context.Context, arg MarkPostReadParams) error
+	MarkPostUnread(ctx context.Context, arg MarkPostUnreadParams) error
+	// the posts of every followed feed published before the date, or stored before it when they have no date
+	MarkReadBefore(ctx context.Context, arg MarkReadBeforeParams) (int64, error)
+	MarkUnreadBefore(ctx context.Context, arg MarkUnreadBeforeParams) (int64, error)
+	Mark_Feed_Fetched(ctx context.Context, id uuid.UUID) error
+	// query is a to_tsquery string (see internal/search), the filters are skipped when NULL / false.
+	// post_search(...) is spelled out the way the index has it, so the index is used
+	SearchPostsPostgres(ctx context.Context, arg SearchPostsPostgresParams) ([]SearchPostsPostgresRow, error)
+	SetFeedCredentials(ctx context.Context, arg SetFeedCredentialsParams) error
+	SetFeedFavicon(ctx context.Context, arg SetFeedFaviconParams) error
+	SetFeedFullText(ctx context.Context, arg SetFeedFullTextParams) error
+	SetFeedImage(ctx context.Context, arg SetFeedImageParams) error
+	SetFeedKind(ctx context.Context, arg SetFeedKindParams) error
+	SetFeedMetadata(ctx context.Context, arg SetFeedMetadataParams) error
+	SetFeedSelectors(ctx context.Context, arg SetFeedSelectorsParams) error
+	SetFollowFolder(ctx context.Context, arg SetFollowFolderParams) (int64, error)
+	SetPostArchive(ctx context.Context, arg SetPostArchiveParams) error
+	SetPostContent(ctx context.Context, arg SetPostContentParams) error
+	SetWebSubLease(ctx context.Context, arg SetWebSubLeaseParams) error
+	// saving a post again refreshes the copy, and the note when a new one is given
+	UpsertBookmark(ctx context.Context, arg UpsertBookmarkParams) (Bookmark, error)
+	UpsertWebSubSubscription(ctx context.Context, arg UpsertWebSubSubscriptionParams) (WebsubSubscription, error)
+}
+
+var _ Querier = (*Queries)(nil)
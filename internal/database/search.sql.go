@@ -0,0 +1,95 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: search.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const searchPostsPostgres = `-- name: SearchPostsPostgres :many
+SELECT posts.id, posts.title, posts.url, posts.published_at, feeds.name AS feed_name,
+    ts_rank_cd(post_search(posts.title, posts.description, posts.content), to_tsquery('english', $1)) AS rank,
+    ts_headline('english',
+        regexp_replace(coalesce(posts.content, posts.description), '<[^>]*>', ' ', 'g'),
+        to_tsquery('english', $1),
+        'StartSel=«, StopSel=», MaxFragments=2, MinWords=8, MaxWords=20, FragmentDelimiter=" … "')::text AS snippet
+FROM posts
+JOIN feeds ON feeds.id = posts.feed_id
+WHERE post_search(posts.title, posts.description, posts.content) @@ to_tsquery('english', $1)
+    AND ($2::text IS NULL OR feeds.name = $2)
+    AND ($3::timestamp IS NULL OR posts.published_at >= $3)
+    AND ($4::timestamp IS NULL OR posts.published_at < $4)
+    AND (NOT $5::boolean OR EXISTS (
+        SELECT 1 FROM feed_follows
+        WHERE feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = $6
+    ))
+ORDER BY rank DESC, posts.published_at DESC NULLS LAST
+LIMIT $7
+`
+
+type SearchPostsPostgresParams struct {
+	Query        string
+	FeedName     sql.NullString
+	Since        sql.NullTime
+	Until        sql.NullTime
+	FollowedOnly bool
+	UserID       uuid.NullUUID
+	MaxResults   int32
+}
+
+type SearchPostsPostgresRow struct {
+	ID          uuid.UUID
+	Title       string
+	Url         string
+	PublishedAt sql.NullTime
+	FeedName    string
+	Rank        float32
+	Snippet     string
+}
+
+// query is a to_tsquery string (see internal/search), the filters are skipped when NULL / false.
+// post_search(...) is spelled out the way the index has it, so the index is used
+func (q *Queries) SearchPostsPostgres(ctx context.Context, arg SearchPostsPostgresParams) ([]SearchPostsPostgresRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchPostsPostgres,
+		arg.Query,
+		arg.FeedName,
+		arg.Since,
+		arg.Until,
+		arg.FollowedOnly,
+		arg.UserID,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchPostsPostgresRow
+	for rows.Next() {
+		var i SearchPostsPostgresRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Url,
+			&i.PublishedAt,
+			&i.FeedName,
+			&i.Rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,210 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/azhagan2/blog_aggregator/internal/search"
+	"github.com/azhagan2/blog_aggregator/internal/storage"
+)
+
+/* Not generated: what sqlc can't write for us. CreatePosts stores many posts with one multi-row INSERT
+(sqlc only batches with COPY on pgx, and we run on lib/pq and SQLite), InTx runs a set of queries in
+one transaction, and SearchPosts runs SearchPostsPostgres or, on SQLite, the same search on FTS5, which
+sqlc's Postgres parser can't read. Store is the generated Querier plus these, and it's what the commands run on. */
+
+type Store interface {
+	Querier
+	CreatePosts(ctx context.Context, arg []CreatePostParams) ([]Post, error)
+	InTx(ctx context.Context, fn func(q Store) error) error
+	SearchPosts(ctx context.Context, query search.Query, arg SearchPostsParams) ([]SearchPostsRow, error)
+}
+
+// SearchPostsParams and SearchPostsRow are the same on both databases, SearchPosts fills in arg.Query
+type (
+	SearchPostsParams = SearchPostsPostgresParams
+	SearchPostsRow    = SearchPostsPostgresRow
+)
+
+var _ Store = (*Queries)(nil)
+
+// postsPerInsert keeps one INSERT under the bind parameter limits, 8 per post (SQLite allows 32766, Postgres 65535)
+const postsPerInsert = 1000
+
+// CreatePosts inserts the posts that aren't stored yet and returns those, a post whose url or title is
+// already taken is skipped (ON CONFLICT DO NOTHING), so a duplicate doesn't abort the transaction it's in
+func (q *Queries) CreatePosts(ctx context.Context, arg []CreatePostParams) ([]Post, error) {
+	var created []Post
+	for len(arg) > 0 {
+		n := min(len(arg), postsPerInsert)
+		posts, err := q.insertPosts(ctx, arg[:n])
+		if err != nil {
+			return created, err
+		}
+		created = append(created, posts...)
+		arg = arg[n:]
+	}
+	return created, nil
+}
+
+func (q *Queries) insertPosts(ctx context.Context, arg []CreatePostParams) ([]Post, error) {
+	var query strings.Builder
+	query.WriteString("INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)\nVALUES ")
+	args := make([]interface{}, 0, len(arg)*8)
+	for i, p := range arg {
+		if i > 0 {
+			query.WriteString(",\n    ")
+		}
+		n := len(args)
+		fmt.Fprintf(&query, "($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8)
+		args = append(args, p.ID, p.CreatedAt, p.UpdatedAt, p.Title, p.Url, p.Description, p.PublishedAt, p.FeedID)
+	}
+	query.WriteString("\nON CONFLICT DO NOTHING\nRETURNING created_at, updated_at, title, url, description, published_at, content, archive_path, archived_at, id, feed_id")
+
+	rows, err := q.db.QueryContext(ctx, query.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.Content,
+			&i.ArchivePath,
+			&i.ArchivedAt,
+			&i.ID,
+			&i.FeedID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// InTx runs fn with queries that all go through one transaction, committed when fn returns nil and rolled
+// back otherwise. Queries that are already in a transaction (from WithTx) run fn in that one.
+func (q *Queries) InTx(ctx context.Context, fn func(q Store) error) error {
+	db, ok := q.db.(interface {
+		BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+	})
+	if !ok {
+		return fn(q)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting a transaction %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(&Queries{db: dialectTx{Tx: tx, dialect: q.dialect()}}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// dialectTx is a transaction that remembers which database it's on, for the queries that differ
+type dialectTx struct {
+	*sql.Tx
+	dialect storage.Dialect
+}
+
+// dialect is the database q runs on, anything that isn't a storage.DB (or a transaction of one) is taken for Postgres
+func (q *Queries) dialect() storage.Dialect {
+	switch db := q.db.(type) {
+	case *storage.DB:
+		return db.Dialect
+	case dialectTx:
+		return db.dialect
+	}
+	return storage.Postgres
+}
+
+// SearchPosts finds the posts matching query, with the tsvector index on Postgres and the posts_fts table on SQLite
+
+func (q *Queries) SearchPosts(ctx context.Context, query search.Query, arg SearchPostsParams) ([]SearchPostsRow, error) {
+	if q.dialect() == storage.SQLite {
+		arg.Query = query.FTS5()
+		return q.searchPostsSQLite(ctx, arg)
+	}
+	arg.Query = query.TSQuery()
+	return q.SearchPostsPostgres(ctx, arg)
+}
+
+const searchPostsSQLite = `
+SELECT posts.id, posts.title, posts.url, posts.published_at, feeds.name AS feed_name,
+    -bm25(posts_fts, 0.0, 10.0, 1.0) AS rank,
+    snippet(posts_fts, 2, '«', '»', ' … ', 20) AS snippet
+FROM posts_fts
+JOIN posts ON posts.id = posts_fts.post_id
+JOIN feeds ON feeds.id = posts.feed_id
+WHERE posts_fts MATCH $1
+    AND ($2 IS NULL OR feeds.name = $2)
+    AND ($3 IS NULL OR julianday(posts.published_at) >= julianday($3))
+    AND ($4 IS NULL OR julianday(posts.published_at) < julianday($4))
+    AND (NOT $5 OR EXISTS (
+        SELECT 1 FROM feed_follows
+        WHERE feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = $6
+    ))
+ORDER BY rank DESC, posts.published_at DESC
+LIMIT $7
+`
+
+// searchPostsSQLite is SearchPostsPostgres on the posts_fts table, arg.Query is an FTS5 MATCH expression.
+// bm25 ranks lower as better, so it's negated to sort like ts_rank_cd, with the title weighted 10 to 1.
+func (q *Queries) searchPostsSQLite(ctx context.Context, arg SearchPostsParams) ([]SearchPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchPostsSQLite,
+		arg.Query,
+		arg.FeedName,
+		arg.Since,
+		arg.Until,
+		arg.FollowedOnly,
+		arg.UserID,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchPostsRow
+	for rows.Next() {
+		var i SearchPostsRow
+		var rank float64
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Url,
+			&i.PublishedAt,
+			&i.FeedName,
+			&rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		i.Rank = float32(rank)
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,274 @@
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/azhagan2/blog_aggregator/internal/database"
+	"github.com/azhagan2/blog_aggregator/internal/migrate"
+	"github.com/azhagan2/blog_aggregator/internal/search"
+	"github.com/azhagan2/blog_aggregator/internal/storage"
+	"github.com/azhagan2/blog_aggregator/sql/schema"
+)
+
+// newSQLite is a fresh SQLite database with every migration applied, and a feed to add posts to
+func newSQLite(t *testing.T) (*database.Queries, database.Feed) {
+	t.Helper()
+	q := database.New(openSQLite(t))
+	feed, err := q.CreateFeed(context.Background(), database.CreateFeedParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(),
+		Name: "blog", Url: "https://blog.example.com/feed.xml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q, feed
+}
+
+func openSQLite(t *testing.T) *storage.DB {
+	t.Helper()
+	ctx := context.Background()
+	db, err := storage.Open(ctx, "sqlite://"+filepath.Join(t.TempDir(), "gator.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrations, err := migrate.Load(schema.Migrations, db.Dialect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrate.Up(ctx, db, migrations); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func newPost(feed database.Feed, title, url string) database.CreatePostParams {
+	return database.CreatePostParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), Title: title, Url: url,
+		FeedID: uuid.NullUUID{UUID: feed.ID, Valid: true}}
+}
+
+func TestCreatePosts(t *testing.T) {
+	q, feed := newSQLite(t)
+	ctx := context.Background()
+
+	first, err := q.CreatePosts(ctx, []database.CreatePostParams{
+		newPost(feed, "One", "https://blog.example.com/1"),
+		newPost(feed, "Two", "https://blog.example.com/2"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 2 {
+		t.Fatalf("created %d posts, want 2", len(first))
+	}
+
+	// one new post, one url and one title we already have, and a duplicate inside the batch itself
+	second, err := q.CreatePosts(ctx, []database.CreatePostParams{
+		newPost(feed, "Three", "https://blog.example.com/3"),
+		newPost(feed, "One again", "https://blog.example.com/1"),
+		newPost(feed, "Two", "https://blog.example.com/two"),
+		newPost(feed, "Three", "https://blog.example.com/3"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(second) != 1 || second[0].Title != "Three" || second[0].FeedID.UUID != feed.ID {
+		t.Errorf("second batch created %+v, want just Three", second)
+	}
+}
+
+func TestCreatePostsManyInserts(t *testing.T) {
+	q, feed := newSQLite(t)
+
+	posts := make([]database.CreatePostParams, 2500)
+	for i := range posts {
+		posts[i] = newPost(feed, uuid.NewString(), "https://blog.example.com/"+uuid.NewString())
+	}
+	created, err := q.CreatePosts(context.Background(), posts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != len(posts) {
+		t.Errorf("created %d posts, want %d", len(created), len(posts))
+	}
+}
+
+func TestInTx(t *testing.T) {
+	q, feed := newSQLite(t)
+	ctx := context.Background()
+
+	failed := errors.New("failed")
+	err := q.InTx(ctx, func(tx database.Store) error {
+		if _, err := tx.CreatePosts(ctx, []database.CreatePostParams{newPost(feed, "Rolled back", "https://blog.example.com/rb")}); err != nil {
+			return err
+		}
+		// a nested InTx joins the outer transaction
+		return tx.InTx(ctx, func(tx database.Store) error { return failed })
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("InTx returned %v, want the error of fn", err)
+	}
+	if _, err := q.GetPostByURL(ctx, "https://blog.example.com/rb"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("post of a failed transaction: %v, want sql.ErrNoRows", err)
+	}
+
+	err = q.InTx(ctx, func(tx database.Store) error {
+		_, err := tx.CreatePosts(ctx, []database.CreatePostParams{newPost(feed, "Committed", "https://blog.example.com/c")})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.GetPostByURL(ctx, "https://blog.example.com/c"); err != nil {
+		t.Errorf("post of a committed transaction: %v", err)
+	}
+}
+
+func TestSearchPostsSQLite(t *testing.T) {
+	q, feed := newSQLite(t)
+	ctx := context.Background()
+
+	day := func(d int) sql.NullTime {
+		return sql.NullTime{Time: time.Date(2024, 1, d, 12, 0, 0, 0, time.UTC), Valid: true}
+	}
+	posts := []database.CreatePostParams{
+		newPost(feed, "Error handling in Go", "https://blog.example.com/errors"),
+		newPost(feed, "Generics", "https://blog.example.com/generics"),
+	}
+	posts[0].Description = "<p>Wrapping &amp; checking errors with <b>errors.Is</b></p>"
+	posts[0].PublishedAt = day(1)
+	posts[1].Description = "<p>Type parameters, and how error handling changes with them</p>"
+	posts[1].PublishedAt = day(2)
+	if _, err := q.CreatePosts(ctx, posts); err != nil {
+		t.Fatal(err)
+	}
+
+	find := func(query string, params database.SearchPostsParams) ([]database.SearchPostsRow, error) {
+		parsed, err := search.Parse(query)
+		if err != nil {
+			return nil, err
+		}
+		params.MaxResults = 10
+		return q.SearchPosts(ctx, parsed, params)
+	}
+
+	tests := []struct {
+		query  string
+		params database.SearchPostsParams
+		want   []string
+	}{
+		// the title weighs more, so the post titled with the phrase ranks first
+		{`"error handling"`, database.SearchPostsParams{}, []string{"Error handling in Go", "Generics"}},
+		{`param*`, database.SearchPostsParams{}, []string{"Generics"}},
+		{`error -generics`, database.SearchPostsParams{}, []string{"Error handling in Go"}},
+		{`error`, database.SearchPostsParams{Since: day(2)}, []string{"Generics"}},
+		{`error`, database.SearchPostsParams{Until: day(2)}, []string{"Error handling in Go"}},
+		{`error`, database.SearchPostsParams{FeedName: sql.NullString{String: "other", Valid: true}}, nil},
+		{`error`, database.SearchPostsParams{FollowedOnly: true, UserID: uuid.NullUUID{UUID: uuid.New(), Valid: true}}, nil},
+	}
+	for _, tt := range tests {
+		rows, err := find(tt.query, tt.params)
+		if err != nil {
+			t.Errorf("%s: %v", tt.query, err)
+			continue
+		}
+		var got []string
+		for _, row := range rows {
+			got = append(got, row.Title)
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("%s %+v found %q, want %q", tt.query, tt.params, got, tt.want)
+		}
+	}
+
+	rows, err := find("checking", database.SearchPostsParams{})
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("found %v, %v", rows, err)
+	}
+	if !strings.Contains(rows[0].Snippet, "«checking»") || rows[0].FeedName != "blog" {
+		t.Errorf("row = %+v", rows[0])
+	}
+
+	// the index follows updates and deletes of posts
+	err = q.SetPostContent(ctx, database.SetPostContentParams{ID: rows[0].ID, Content: sql.NullString{String: "now about closures", Valid: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err = find("closures", database.SearchPostsParams{})
+	if err != nil || len(rows) != 1 {
+		t.Errorf("after the update found %v, %v", rows, err)
+	}
+
+	// a transaction still knows it's on SQLite
+	err = q.InTx(ctx, func(tx database.Store) error {
+		parsed, err := search.Parse("closures")
+		if err != nil {
+			return err
+		}
+		rows, err = tx.SearchPosts(ctx, parsed, database.SearchPostsParams{MaxResults: 10})
+		return err
+	})
+	if err != nil || len(rows) != 1 {
+		t.Errorf("in a transaction found %v, %v", rows, err)
+	}
+}
+
+func TestBookmarkOutlivesFeed(t *testing.T) {
+	db := openSQLite(t)
+	q := database.New(db)
+	ctx := context.Background()
+
+	user, err := q.CreateUser(ctx, database.CreateUserParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), Name: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed, err := q.CreateFeed(ctx, database.CreateFeedParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(),
+		Name: "blog", Url: "https://blog.example.com/feed.xml", UserID: uuid.NullUUID{UUID: user.ID, Valid: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	posts, err := q.CreatePosts(ctx, []database.CreatePostParams{newPost(feed, "One", "https://blog.example.com/1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := database.UpsertBookmarkParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(),
+		UserID: user.ID, PostID: uuid.NullUUID{UUID: posts[0].ID, Valid: true}, Url: posts[0].Url, Title: posts[0].Title,
+		FeedName: sql.NullString{String: feed.Name, Valid: true}, Note: sql.NullString{String: "later", Valid: true}}
+	bookmark, err := q.UpsertBookmark(ctx, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.AddBookmarkTag(ctx, database.AddBookmarkTagParams{BookmarkID: bookmark.ID, Tag: "go"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// saving again without a note keeps the one we have
+	params.ID, params.Note = uuid.New(), sql.NullString{}
+	again, err := q.UpsertBookmark(ctx, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != bookmark.ID || again.Note.String != "later" {
+		t.Errorf("saving again gave %+v, want the same bookmark with its note", again)
+	}
+
+	if _, err := db.ExecContext(ctx, "DELETE FROM feeds"); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := q.GetBookmarks(ctx, database.GetBookmarksParams{UserID: user.ID, Tag: sql.NullString{String: "go", Valid: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 1 || saved[0].PostID.Valid || saved[0].Title != "One" || saved[0].FeedName.String != "blog" {
+		t.Errorf("after deleting the feed the bookmarks are %+v, want One without its post", saved)
+	}
+}
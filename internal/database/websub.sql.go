@@ -0,0 +1,151 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: websub.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const deleteWebSubSubscription = `-- name: DeleteWebSubSubscription :exec
+DELETE FROM websub_subscriptions
+WHERE feed_id = $1
+`
+
+func (q *Queries) DeleteWebSubSubscription(ctx context.Context, feedID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteWebSubSubscription, feedID)
+	return err
+}
+
+const getFeedByID = `-- name: GetFeedByID :one
+SELECT created_at, updated_at, name, url, last_fetched_at, fetch_full_text, kind, item_selector, title_selector, link_selector, date_selector, summary_selector, credentials, image_url, favicon_path, title, description, site_url, language, id, user_id
+FROM feeds
+WHERE id = $1
+`
+
+func (q *Queries) GetFeedByID(ctx context.Context, id uuid.UUID) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByID, id)
+	var i Feed
+	err := row.Scan(
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.LastFetchedAt,
+		&i.FetchFullText,
+		&i.Kind,
+		&i.ItemSelector,
+		&i.TitleSelector,
+		&i.LinkSelector,
+		&i.DateSelector,
+		&i.SummarySelector,
+		&i.Credentials,
+		&i.ImageUrl,
+		&i.FaviconPath,
+		&i.Title,
+		&i.Description,
+		&i.SiteUrl,
+		&i.Language,
+		&i.ID,
+		&i.UserID,
+	)
+	return i, err
+}
+
+const getWebSubSubscription = `-- name: GetWebSubSubscription :one
+SELECT id, created_at, updated_at, feed_id, hub_url, topic_url, secret, lease_expires_at
+FROM websub_subscriptions
+WHERE feed_id = $1
+`
+
+func (q *Queries) GetWebSubSubscription(ctx context.Context, feedID uuid.UUID) (WebsubSubscription, error) {
+	row := q.db.QueryRowContext(ctx, getWebSubSubscription, feedID)
+	var i WebsubSubscription
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FeedID,
+		&i.HubUrl,
+		&i.TopicUrl,
+		&i.Secret,
+		&i.LeaseExpiresAt,
+	)
+	return i, err
+}
+
+const setWebSubLease = `-- name: SetWebSubLease :exec
+UPDATE websub_subscriptions
+SET lease_expires_at = $2,
+    updated_at = NOW()
+WHERE feed_id = $1
+`
+
+type SetWebSubLeaseParams struct {
+	FeedID         uuid.UUID
+	LeaseExpiresAt sql.NullTime
+}
+
+func (q *Queries) SetWebSubLease(ctx context.Context, arg SetWebSubLeaseParams) error {
+	_, err := q.db.ExecContext(ctx, setWebSubLease, arg.FeedID, arg.LeaseExpiresAt)
+	return err
+}
+
+const upsertWebSubSubscription = `-- name: UpsertWebSubSubscription :one
+INSERT INTO websub_subscriptions (id, created_at, updated_at, feed_id, hub_url, topic_url, secret)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+)
+ON CONFLICT (feed_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    hub_url = EXCLUDED.hub_url,
+    topic_url = EXCLUDED.topic_url,
+    secret = EXCLUDED.secret
+RETURNING id, created_at, updated_at, feed_id, hub_url, topic_url, secret, lease_expires_at
+`
+
+type UpsertWebSubSubscriptionParams struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	FeedID    uuid.UUID
+	HubUrl    string
+	TopicUrl  string
+	Secret    string
+}
+
+func (q *Queries) UpsertWebSubSubscription(ctx context.Context, arg UpsertWebSubSubscriptionParams) (WebsubSubscription, error) {
+	row := q.db.QueryRowContext(ctx, upsertWebSubSubscription,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.FeedID,
+		arg.HubUrl,
+		arg.TopicUrl,
+		arg.Secret,
+	)
+	var i WebsubSubscription
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FeedID,
+		&i.HubUrl,
+		&i.TopicUrl,
+		&i.Secret,
+		&i.LeaseExpiresAt,
+	)
+	return i, err
+}
@@ -0,0 +1,265 @@
+package extract
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ErrNoContent means the page didn't have anything that looks like an article (an index page, a login wall, ...)
+var ErrNoContent = errors.New("no article content found")
+
+// minArticleLength is how much text (in bytes) the winning block needs before we trust it over the feed's summary.
+const minArticleLength = 200
+
+var (
+	unlikely = regexp.MustCompile(`(?i)comment|community|footer|sidebar|widget|nav|menu|share|social|related|promo|advert|sponsor|banner|cookie|popup|subscribe|newsletter|breadcrumb|pagination`)
+	likely   = regexp.MustCompile(`(?i)article|body|content|entry|main|post|story|text|blog`)
+)
+
+// junk elements are never part of an article, they're dropped before scoring.
+var junk = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Iframe: true, atom.Form: true,
+	atom.Nav: true, atom.Footer: true, atom.Aside: true, atom.Svg: true, atom.Button: true,
+	atom.Input: true, atom.Select: true, atom.Textarea: true, atom.Link: true, atom.Meta: true,
+}
+
+// keptAttrs are the only attributes that survive cleaning, everything else (class, style, onclick...) goes.
+var keptAttrs = map[string]bool{"href": true, "src": true, "alt": true, "title": true}
+
+/* Article is a small readability-style extractor. It parses the page, throws away the obvious chrome
+(scripts, navigation, sidebars, comment sections), scores every block by how much real paragraph text it
+holds, and returns the best block as cleaned HTML with links and images made absolute against pageURL.
+
+The input is just a reader, so it works the same on a downloaded page or on a local HTML file. */
+
+func Article(r io.Reader, pageURL string) (string, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", fmt.Errorf("error parsing the article html: %w", err)
+	}
+
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid article url: %w", err)
+	}
+
+	removeJunk(doc)
+
+	scores := map[*html.Node]float64{}
+	score(doc, scores)
+
+	var best *html.Node
+	bestScore := 0.0
+	for node, s := range scores {
+		s *= 1 - linkDensity(node)
+		if best == nil || s > bestScore {
+			best, bestScore = node, s
+		}
+	}
+	if best == nil || len(strings.TrimSpace(textOf(best))) < minArticleLength {
+		return "", ErrNoContent
+	}
+
+	clean(best, base)
+
+	var out bytes.Buffer
+	for c := best.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(&out, c); err != nil {
+			return "", fmt.Errorf("error rendering the article html: %w", err)
+		}
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// removeJunk drops script/nav/etc. and anything whose class or id says it's a sidebar, comments, share buttons...
+func removeJunk(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.CommentNode || (c.Type == html.ElementNode && isJunk(c)) {
+			n.RemoveChild(c)
+		} else {
+			removeJunk(c)
+		}
+		c = next
+	}
+}
+
+func isJunk(n *html.Node) bool {
+	if junk[n.DataAtom] {
+		return true
+	}
+	switch n.DataAtom {
+	case atom.Html, atom.Body, atom.Article, atom.Main:
+		return false
+	}
+	names := attr(n, "class") + " " + attr(n, "id")
+	return unlikely.MatchString(names) && !likely.MatchString(names)
+}
+
+/* score walks every paragraph-ish element, and gives its parent (and half as much to its grandparent)
+points for the amount of text and the number of commas in it. Long, comma-heavy prose is what articles
+are made of, link lists and teasers aren't. */
+
+func score(n *html.Node, scores map[*html.Node]float64) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		score(c, scores)
+	}
+
+	if n.Type != html.ElementNode || (n.DataAtom != atom.P && n.DataAtom != atom.Pre && n.DataAtom != atom.Td) {
+		return
+	}
+
+	text := strings.TrimSpace(textOf(n))
+	if len(text) < 25 {
+		return
+	}
+	points := 1 + float64(strings.Count(text, ",")) + min(float64(len(text))/100, 3)
+
+	parent := n.Parent
+	if parent == nil || parent.Type != html.ElementNode {
+		return
+	}
+	if _, ok := scores[parent]; !ok {
+		scores[parent] = baseScore(parent)
+	}
+	scores[parent] += points
+
+	grandparent := parent.Parent
+	if grandparent == nil || grandparent.Type != html.ElementNode {
+		return
+	}
+	if _, ok := scores[grandparent]; !ok {
+		scores[grandparent] = baseScore(grandparent)
+	}
+	scores[grandparent] += points / 2
+}
+
+// baseScore is the head start an element gets from its tag and its class/id names.
+func baseScore(n *html.Node) float64 {
+	s := 0.0
+	switch n.DataAtom {
+	case atom.Article, atom.Main:
+		s += 10
+	case atom.Div, atom.Section:
+		s += 5
+	case atom.Pre, atom.Td, atom.Blockquote:
+		s += 3
+	case atom.Ol, atom.Ul, atom.Dl, atom.Dd, atom.Dt, atom.Li, atom.Address:
+		s -= 3
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Th:
+		s -= 5
+	}
+
+	names := attr(n, "class") + " " + attr(n, "id")
+	if likely.MatchString(names) {
+		s += 25
+	}
+	if unlikely.MatchString(names) {
+		s -= 25
+	}
+	return s
+}
+
+// linkDensity is the share of an element's text that sits inside links, close to 1 for menus and link lists.
+func linkDensity(n *html.Node) float64 {
+	total := len(textOf(n))
+	if total == 0 {
+		return 0
+	}
+	linked := 0
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.A {
+			linked += len(textOf(n))
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return float64(linked) / float64(total)
+}
+
+// clean strips presentational attributes, makes links absolute, and drops leftover link lists and empty blocks.
+func clean(n *html.Node, base *url.URL) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type != html.ElementNode {
+			c = next
+			continue
+		}
+
+		clean(c, base)
+
+		switch c.DataAtom {
+		case atom.Div, atom.Ul, atom.Ol, atom.Table, atom.Section:
+			if linkDensity(c) > 0.5 {
+				n.RemoveChild(c)
+			}
+		case atom.P:
+			if strings.TrimSpace(textOf(c)) == "" && !hasImage(c) {
+				n.RemoveChild(c)
+			}
+		}
+		c = next
+	}
+
+	if n.Type != html.ElementNode {
+		return
+	}
+
+	attrs := n.Attr[:0]
+	for _, a := range n.Attr {
+		if !keptAttrs[a.Key] {
+			continue
+		}
+		if a.Key == "href" || a.Key == "src" {
+			if ref, err := base.Parse(strings.TrimSpace(a.Val)); err == nil {
+				a.Val = ref.String()
+			}
+		}
+		attrs = append(attrs, a)
+	}
+	n.Attr = attrs
+}
+
+func hasImage(n *html.Node) bool {
+	if n.Type == html.ElementNode && n.DataAtom == atom.Img {
+		return true
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if hasImage(c) {
+			return true
+		}
+	}
+	return false
+}
+
+func textOf(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textOf(c))
+	}
+	return b.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
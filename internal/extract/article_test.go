@@ -0,0 +1,75 @@
+package extract
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestArticle(t *testing.T) {
+	tests := []struct {
+		fixture string
+		pageURL string
+		kept    []string
+		dropped []string
+	}{
+		{
+			fixture: "wordpress.html",
+			pageURL: "https://gophers.example.com/2024/01/channels/",
+			kept: []string{
+				"Channels are the pipes",
+				"a synchronization point",
+				`<a href="https://gophers.example.com/2024/01/select">`,
+				`<img src="https://gophers.example.com/2024/01/channels/images/pipes.png" alt="Two goroutines and a channel"/>`,
+			},
+			dropped: []string{
+				"Archive", "Share on Twitter", "Great article", "Recent posts", "Copyright", "window.analytics",
+				"class=", "style=", "<p>   </p>",
+			},
+		},
+		{
+			fixture: "main.html",
+			pageURL: "https://tool.example.com/blog/2.0",
+			kept:    []string{"rewrites the storage engine", "read the migration notes", "tool upgrade --to 2.0"},
+			dropped: []string{"Download", "Version 1.9 is out", "Subscribe to our newsletter"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			f, err := os.Open("testdata/" + tt.fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			got, err := Article(f, tt.pageURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.kept {
+				if !strings.Contains(got, want) {
+					t.Errorf("article is missing %q:\n%s", want, got)
+				}
+			}
+			for _, junk := range tt.dropped {
+				if strings.Contains(got, junk) {
+					t.Errorf("article still has %q:\n%s", junk, got)
+				}
+			}
+		})
+	}
+}
+
+func TestArticleNoContent(t *testing.T) {
+	f, err := os.Open("testdata/index.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if _, err := Article(f, "https://gophers.example.com/"); !errors.Is(err, ErrNoContent) {
+		t.Errorf("Article(index page) = %v, want ErrNoContent", err)
+	}
+}
@@ -0,0 +1,270 @@
+package migrate
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/azhagan2/blog_aggregator/internal/storage"
+)
+
+/* The migrations in sql/schema are goose files: "-- +goose up" starts the SQL that applies one, "-- +goose Down"
+the SQL that undoes it, and the number in front of the file name is its version. This package runs them without
+goose, and keeps track of them in goose's own table (goose_db_version), so a database set up with the goose CLI
+carries on where goose left off and the other way around.
+
+Where SQLite can't run a migration as it is, a file with the same version under sqlite/ replaces it there. */
+
+const versionTable = "goose_db_version"
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is one migration and whether the database has it
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Load reads every .sql file at the top of fsys, with the dialect's replacements from its own directory, sorted by version
+
+func Load(fsys fs.FS, dialect storage.Dialect) ([]Migration, error) {
+	base, err := loadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := loadDir(fsys, string(dialect))
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]Migration{}
+	for _, m := range base {
+		byVersion[m.Version] = m
+	}
+	for _, m := range overrides {
+		if _, ok := byVersion[m.Version]; !ok {
+			return nil, fmt.Errorf("%s/%s doesn't replace any migration", dialect, m.Name)
+		}
+		byVersion[m.Version] = m
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func loadDir(fsys fs.FS, dir string) ([]Migration, error) {
+	names, err := fs.Glob(fsys, path.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	seen := map[int64]string{}
+	for _, name := range names {
+		prefix, _, ok := strings.Cut(path.Base(name), "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s doesn't start with a version number", name)
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s have the same version", other, name)
+		}
+		seen[version] = name
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		up, down, err := split(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", name, err)
+		}
+		migrations = append(migrations, Migration{
+			Version: version,
+			Name:    strings.TrimSuffix(path.Base(name), ".sql"),
+			Up:      up,
+			Down:    down,
+		})
+	}
+	return migrations, nil
+}
+
+// split cuts a goose file into its up and down SQL, the annotations themselves are left out
+func split(src string) (up, down string, err error) {
+	var upSQL, downSQL strings.Builder
+	var current *strings.Builder
+
+	scanner := bufio.NewScanner(strings.NewReader(src))
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		annotation := strings.ToLower(strings.Join(strings.Fields(line), " "))
+		switch {
+		case annotation == "-- +goose up":
+			current = &upSQL
+			continue
+		case annotation == "-- +goose down":
+			current = &downSQL
+			continue
+		case strings.HasPrefix(annotation, "-- +goose "):
+			// StatementBegin/End only matter to goose's own statement splitting, we send each part whole
+			continue
+		}
+		if current != nil {
+			current.WriteString(line)
+			current.WriteString("\n")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", "", err
+	}
+	if strings.TrimSpace(upSQL.String()) == "" {
+		return "", "", fmt.Errorf("no -- +goose up section")
+	}
+	return upSQL.String(), downSQL.String(), nil
+}
+
+// applied returns when each applied version was applied, creating goose's table on a fresh database
+
+func applied(ctx context.Context, db *storage.DB) (map[int64]time.Time, error) {
+	// the same table goose makes on each database
+	create := `CREATE TABLE IF NOT EXISTS ` + versionTable + ` (
+		id SERIAL PRIMARY KEY,
+		version_id BIGINT NOT NULL,
+		is_applied BOOLEAN NOT NULL,
+		tstamp TIMESTAMP DEFAULT NOW()
+	)`
+	if db.Dialect == storage.SQLite {
+		create = `CREATE TABLE IF NOT EXISTS ` + versionTable + ` (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			version_id INTEGER NOT NULL,
+			is_applied INTEGER NOT NULL,
+			tstamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`
+	}
+
+	_, err := db.ExecContext(ctx, create)
+	if err != nil {
+		return nil, fmt.Errorf("error creating %s: %w", versionTable, err)
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT version_id, is_applied, tstamp FROM `+versionTable+` ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", versionTable, err)
+	}
+	defer rows.Close()
+
+	// older goose versions recorded a down as a new row with is_applied false, so the last row of a version wins
+	versions := map[int64]time.Time{}
+	for rows.Next() {
+		var version int64
+		var isApplied bool
+		var at sql.NullTime
+		if err := rows.Scan(&version, &isApplied, &at); err != nil {
+			return nil, err
+		}
+		if isApplied && version > 0 {
+			versions[version] = at.Time
+		} else {
+			delete(versions, version)
+		}
+	}
+	return versions, rows.Err()
+}
+
+// StatusOf lists every migration with whether it's applied
+
+func StatusOf(ctx context.Context, db *storage.DB, migrations []Migration) ([]Status, error) {
+	versions, err := applied(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(migrations))
+	for i, m := range migrations {
+		at, ok := versions[m.Version]
+		statuses[i] = Status{Migration: m, Applied: ok, AppliedAt: at}
+	}
+	return statuses, nil
+}
+
+// Up applies every migration the database doesn't have yet, in order, and returns the ones it applied
+
+func Up(ctx context.Context, db *storage.DB, migrations []Migration) ([]Migration, error) {
+	versions, err := applied(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range migrations {
+		if _, ok := versions[m.Version]; ok {
+			continue
+		}
+		err := inTx(ctx, db, m.Up, `INSERT INTO `+versionTable+` (version_id, is_applied) VALUES ($1, TRUE)`, m.Version)
+		if err != nil {
+			return done, fmt.Errorf("error applying %s: %w", m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// Down undoes the latest applied migration, ok is false if there was nothing to undo
+
+func Down(ctx context.Context, db *storage.DB, migrations []Migration) (m Migration, ok bool, err error) {
+	versions, err := applied(ctx, db)
+	if err != nil {
+		return Migration{}, false, err
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m = migrations[i]
+		if _, ok := versions[m.Version]; !ok {
+			continue
+		}
+		if strings.TrimSpace(m.Down) == "" {
+			return m, false, fmt.Errorf("%s has no -- +goose Down section", m.Name)
+		}
+		err := inTx(ctx, db, m.Down, `DELETE FROM `+versionTable+` WHERE version_id = $1`, m.Version)
+		if err != nil {
+			return m, false, fmt.Errorf("error undoing %s: %w", m.Name, err)
+		}
+		return m, true, nil
+	}
+	return Migration{}, false, nil
+}
+
+// inTx runs a migration and records it in one transaction, so a failed migration leaves nothing behind
+func inTx(ctx context.Context, db *storage.DB, migration, record string, version int64) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, migration); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,252 @@
+package migrate
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/azhagan2/blog_aggregator/internal/storage"
+	"github.com/azhagan2/blog_aggregator/sql/schema"
+)
+
+func openSQLite(t *testing.T) *storage.DB {
+	t.Helper()
+	db, err := storage.Open(context.Background(), "sqlite://"+filepath.Join(t.TempDir(), "gator.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func file(up, down string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte("-- +goose Up\n" + up + "\n-- +goose Down\n" + down + "\n")}
+}
+
+// tableExists tells whether name is in the SQLite schema
+func tableExists(t *testing.T, db *storage.DB, name string) bool {
+	t.Helper()
+	var n int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = $1`, name).Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n > 0
+}
+
+func TestSplit(t *testing.T) {
+	up, down, err := split(`-- a comment before anything
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE users (id INTEGER);
+-- +goose StatementEnd
+--   +GOOSE   DOWN
+DROP TABLE users;
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if up != "CREATE TABLE users (id INTEGER);\n" || down != "DROP TABLE users;\n" {
+		t.Errorf("up = %q, down = %q", up, down)
+	}
+
+	if _, _, err := split("-- +goose Down\nDROP TABLE users;\n"); err == nil {
+		t.Error("a file without an up section should fail")
+	}
+	if _, down, err := split("-- +goose Up\nSELECT 1;\n"); err != nil || down != "" {
+		t.Errorf("a file without a down section: down = %q, err = %v", down, err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"002_posts.sql":        file("CREATE TABLE posts (id UUID);", "DROP TABLE posts;"),
+		"001_users.sql":        file("CREATE TABLE users (id UUID);", "DROP TABLE users;"),
+		"sqlite/002_posts.sql": file("CREATE TABLE posts (id TEXT);", "DROP TABLE posts;"),
+		"notes.txt":            {Data: []byte("not a migration")},
+	}
+
+	postgres, err := Load(fsys, storage.Postgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlite, err := Load(fsys, storage.SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(postgres) != 2 || postgres[0].Version != 1 || postgres[1].Name != "002_posts" {
+		t.Fatalf("postgres migrations = %+v", postgres)
+	}
+	// the file under sqlite/ replaces the one with its version, on SQLite only
+	if !strings.Contains(postgres[1].Up, "UUID") || !strings.Contains(sqlite[1].Up, "TEXT") || len(sqlite) != 2 {
+		t.Errorf("postgres up = %q, sqlite up = %q", postgres[1].Up, sqlite[1].Up)
+	}
+
+	bad := map[string]fstest.MapFS{
+		"no version":          {"users.sql": file("SELECT 1;", "")},
+		"version zero":        {"0_users.sql": file("SELECT 1;", "")},
+		"same version":        {"001_users.sql": file("SELECT 1;", ""), "1_people.sql": file("SELECT 1;", "")},
+		"no up section":       {"001_users.sql": {Data: []byte("CREATE TABLE users (id UUID);")}},
+		"override of nothing": {"001_users.sql": file("SELECT 1;", ""), "sqlite/002_posts.sql": file("SELECT 1;", "")},
+		"bad override name":   {"001_users.sql": file("SELECT 1;", ""), "sqlite/users.sql": file("SELECT 1;", "")},
+	}
+	for name, fsys := range bad {
+		if _, err := Load(fsys, storage.SQLite); err == nil {
+			t.Errorf("%s: Load should fail", name)
+		}
+	}
+}
+
+func TestUpDownUp(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	migrations, err := Load(fstest.MapFS{
+		"001_users.sql": file("CREATE TABLE users (id INTEGER PRIMARY KEY);", "DROP TABLE users;"),
+		"002_posts.sql": file("CREATE TABLE posts (id INTEGER PRIMARY KEY);", "DROP TABLE posts;"),
+	}, db.Dialect)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done, err := Up(ctx, db, migrations)
+	if err != nil || len(done) != 2 {
+		t.Fatalf("first up applied %d (%v), want 2", len(done), err)
+	}
+	if !tableExists(t, db, "users") || !tableExists(t, db, "posts") {
+		t.Fatal("up didn't create the tables")
+	}
+
+	m, ok, err := Down(ctx, db, migrations)
+	if err != nil || !ok || m.Version != 2 {
+		t.Fatalf("down undid %s, ok %v (%v), want 002_posts", m.Name, ok, err)
+	}
+	if tableExists(t, db, "posts") || !tableExists(t, db, "users") {
+		t.Error("down should drop posts and only posts")
+	}
+	statuses, err := StatusOf(ctx, db, migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !statuses[0].Applied || statuses[1].Applied {
+		t.Errorf("statuses after down = %+v", statuses)
+	}
+
+	done, err = Up(ctx, db, migrations)
+	if err != nil || len(done) != 1 || done[0].Version != 2 {
+		t.Fatalf("second up applied %+v (%v), want only 002_posts", done, err)
+	}
+	if done, err := Up(ctx, db, migrations); err != nil || len(done) != 0 {
+		t.Errorf("up on an up to date database applied %d (%v)", len(done), err)
+	}
+
+	for range migrations {
+		if _, _, err := Down(ctx, db, migrations); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok, err := Down(ctx, db, migrations); ok || err != nil {
+		t.Errorf("down with nothing applied: ok %v, err %v", ok, err)
+	}
+}
+
+func TestUpRollsBackFailedMigration(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	migrations, err := Load(fstest.MapFS{
+		"001_users.sql": file("CREATE TABLE users (id INTEGER PRIMARY KEY);", "DROP TABLE users;"),
+		"002_posts.sql": file("CREATE TABLE posts (id INTEGER PRIMARY KEY);\nINSERT INTO missing VALUES (1);", "DROP TABLE posts;"),
+	}, db.Dialect)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done, err := Up(ctx, db, migrations)
+	if err == nil || !strings.Contains(err.Error(), "002_posts") {
+		t.Fatalf("err = %v, want 002_posts to fail", err)
+	}
+	if len(done) != 1 || done[0].Version != 1 {
+		t.Errorf("applied %+v, want only 001_users", done)
+	}
+	// the half of 002 that worked is gone and the version isn't recorded
+	if tableExists(t, db, "posts") {
+		t.Error("the failed migration left its table behind")
+	}
+	statuses, err := StatusOf(ctx, db, migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !statuses[0].Applied || statuses[1].Applied {
+		t.Errorf("statuses = %+v", statuses)
+	}
+
+	if _, _, err := Down(ctx, db, []Migration{{Version: 1, Name: "001_users", Up: "SELECT 1;"}}); err == nil {
+		t.Error("down of a migration without a down section should fail")
+	}
+}
+
+func TestGooseVersionTable(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+
+	// how goose leaves its table: version 0 first, and older gooses record a down as a row with is_applied false
+	_, err := db.ExecContext(ctx, `CREATE TABLE goose_db_version (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		version_id INTEGER NOT NULL,
+		is_applied INTEGER NOT NULL,
+		tstamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE users (id INTEGER PRIMARY KEY);
+	INSERT INTO goose_db_version (version_id, is_applied) VALUES (0, 1), (1, 1), (2, 1), (2, 0);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	migrations, err := Load(fstest.MapFS{
+		"001_users.sql": file("CREATE TABLE users (id INTEGER PRIMARY KEY);", "DROP TABLE users;"),
+		"002_posts.sql": file("CREATE TABLE posts (id INTEGER PRIMARY KEY);", "DROP TABLE posts;"),
+	}, db.Dialect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done, err := Up(ctx, db, migrations)
+	if err != nil || len(done) != 1 || done[0].Version != 2 {
+		t.Fatalf("up applied %+v (%v), want only 002_posts", done, err)
+	}
+
+	var rows int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM goose_db_version WHERE version_id = 2 AND is_applied`).Scan(&rows); err != nil {
+		t.Fatal(err)
+	}
+	if rows != 2 {
+		t.Errorf("got %d applied rows for version 2, want goose's old one and ours", rows)
+	}
+}
+
+func TestSchemaUpDownUp(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	migrations, err := Load(schema.Migrations, db.Dialect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(schema.Migrations, storage.Postgres); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Up(ctx, db, migrations); err != nil {
+		t.Fatal(err)
+	}
+	for range migrations {
+		if _, _, err := Down(ctx, db, migrations); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if tableExists(t, db, "users") {
+		t.Error("users is still there after undoing every migration")
+	}
+	if done, err := Up(ctx, db, migrations); err != nil || len(done) != len(migrations) {
+		t.Fatalf("applying again: %d of %d (%v)", len(done), len(migrations), err)
+	}
+}
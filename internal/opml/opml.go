@@ -0,0 +1,122 @@
+package opml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+/* OPML is how feed readers hand subscription lists to each other. Folders are outlines without an
+xmlUrl that hold the feed outlines, so that's where a feed's folder comes from and goes to. Readers
+that keep the list flat put the folder in the category attribute instead ("/Go" or "Go,Friends"),
+which is read when a feed isn't inside a folder outline. Nested folders become "Outer/Inner". */
+
+type Feed struct {
+	Title   string
+	URL     string
+	SiteURL string
+	Folder  string
+}
+
+type document struct {
+	XMLName xml.Name  `xml:"opml"`
+	Version string    `xml:"version,attr"`
+	Title   string    `xml:"head>title"`
+	Body    []outline `xml:"body>outline"`
+}
+
+type outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr,omitempty"`
+	Type     string    `xml:"type,attr,omitempty"`
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string    `xml:"htmlUrl,attr,omitempty"`
+	Category string    `xml:"category,attr,omitempty"`
+	Outlines []outline `xml:"outline"`
+}
+
+// Parse reads the feeds of an OPML document in the order they're listed
+
+func Parse(r io.Reader) ([]Feed, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = charset.NewReaderLabel
+
+	var doc document
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("not an OPML document %w", err)
+	}
+
+	var feeds []Feed
+	collect(doc.Body, "", &feeds)
+	return feeds, nil
+}
+
+func collect(outlines []outline, folder string, feeds *[]Feed) {
+	for _, o := range outlines {
+		name := strings.TrimSpace(o.Text)
+		if name == "" {
+			name = strings.TrimSpace(o.Title)
+		}
+
+		if o.XMLURL == "" {
+			// a folder, or an outline that's only text
+			inner := folder
+			if name != "" {
+				inner = strings.TrimPrefix(folder+"/"+name, "/")
+			}
+			collect(o.Outlines, inner, feeds)
+			continue
+		}
+
+		feed := Feed{Title: name, URL: strings.TrimSpace(o.XMLURL), SiteURL: strings.TrimSpace(o.HTMLURL), Folder: folder}
+		if feed.Folder == "" {
+			feed.Folder = categoryFolder(o.Category)
+		}
+		*feeds = append(*feeds, feed)
+	}
+}
+
+// categoryFolder is the first category of a feed, "/Tech/Go,/Later" is Tech/Go
+
+func categoryFolder(category string) string {
+	first, _, _ := strings.Cut(category, ",")
+	return strings.Trim(strings.TrimSpace(first), "/")
+}
+
+// Write writes feeds as an OPML 2.0 document, the ones without a folder first and then a folder outline
+// for each folder, in the order they come in feeds
+
+func Write(w io.Writer, title string, feeds []Feed) error {
+	var top, grouped []outline
+	folders := map[string]int{}
+
+	for _, f := range feeds {
+		o := outline{Text: f.Title, Title: f.Title, Type: "rss", XMLURL: f.URL, HTMLURL: f.SiteURL}
+		if f.Folder == "" {
+			top = append(top, o)
+			continue
+		}
+		i, ok := folders[f.Folder]
+		if !ok {
+			i = len(grouped)
+			folders[f.Folder] = i
+			grouped = append(grouped, outline{Text: f.Folder, Title: f.Folder})
+		}
+		grouped[i].Outlines = append(grouped[i].Outlines, o)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	doc := document{Version: "2.0", Title: title, Body: append(top, grouped...)}
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,72 @@
+package opml
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	doc := `<?xml version="1.0" encoding="ISO-8859-1"?>
+<opml version="1.0">
+  <head><title>Subscriptions</title></head>
+  <body>
+    <outline text="Loose" type="rss" xmlUrl="https://loose.example.com/feed" htmlUrl="https://loose.example.com/"/>
+    <outline text="Go">
+      <outline title="Go Blog" type="rss" xmlUrl=" https://go.dev/blog/feed.atom "/>
+      <outline text="Tools">
+        <outline text="Linters" type="rss" xmlUrl="https://lint.example.com/rss"/>
+      </outline>
+    </outline>
+    <outline text="Flat" type="rss" xmlUrl="https://flat.example.com/rss" category="/Security/Web,/Later"/>
+    <outline text="Caf` + "\xe9" + `" type="rss" xmlUrl="https://cafe.example.com/rss"/>
+  </body>
+</opml>`
+
+	feeds, err := Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Feed{
+		{Title: "Loose", URL: "https://loose.example.com/feed", SiteURL: "https://loose.example.com/"},
+		{Title: "Go Blog", URL: "https://go.dev/blog/feed.atom", Folder: "Go"},
+		{Title: "Linters", URL: "https://lint.example.com/rss", Folder: "Go/Tools"},
+		{Title: "Flat", URL: "https://flat.example.com/rss", Folder: "Security/Web"},
+		{Title: "Café", URL: "https://cafe.example.com/rss"},
+	}
+	if !reflect.DeepEqual(feeds, want) {
+		t.Errorf("Parse() =\n%+v\nwant\n%+v", feeds, want)
+	}
+
+	if _, err := Parse(strings.NewReader("<rss><channel></channel></rss>")); err == nil {
+		t.Error("Parse of an RSS feed should fail")
+	}
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	feeds := []Feed{
+		{Title: "Go Blog", URL: "https://go.dev/blog/feed.atom", SiteURL: "https://go.dev/blog", Folder: "Go"},
+		{Title: "Loose & free", URL: "https://loose.example.com/feed?a=1&b=2"},
+		{Title: "Linters", URL: "https://lint.example.com/rss", Folder: "Go/Tools"},
+		{Title: "Gophers", URL: "https://gophers.example.com/rss", Folder: "Go"},
+	}
+
+	var out bytes.Buffer
+	if err := Write(&out, "gator subscriptions", feeds); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out.String(), `<?xml version="1.0" encoding="UTF-8"?>`) || !strings.Contains(out.String(), "<title>gator subscriptions</title>") {
+		t.Errorf("unexpected document:\n%s", out.String())
+	}
+
+	parsed, err := Parse(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// feeds without a folder come first, then each folder with its feeds
+	want := []Feed{feeds[1], feeds[0], feeds[3], feeds[2]}
+	if !reflect.DeepEqual(parsed, want) {
+		t.Errorf("round trip =\n%+v\nwant\n%+v", parsed, want)
+	}
+}
@@ -0,0 +1,327 @@
+package render
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// minWidth keeps wrapping sane when the terminal is tiny or deeply nested lists eat the line.
+const minWidth = 20
+
+// indent is one level of nesting (a blockquote or a list item). The first line written inside it
+// gets the first prefix (like "* " or "1. "), every line after that gets rest.
+type indent struct {
+	first string
+	rest  string
+	used  bool
+}
+
+// list remembers whether we are inside an <ol> and which number the next <li> gets.
+type list struct {
+	ordered bool
+	next    int
+}
+
+type renderer struct {
+	width   int
+	out     strings.Builder
+	inline  strings.Builder
+	pre     int
+	preText strings.Builder
+	indents []*indent
+	lists   []*list
+	links   []string
+	started bool
+	pending bool
+	blank   string
+}
+
+/* HTMLToText turns an HTML fragment (like a feed item description) into plain text for the terminal.
+Paragraphs are wrapped to width columns, lists get "*" or "1." markers, blockquotes get "> ",
+<pre> blocks are kept as they are, and links become [n] references listed at the bottom. */
+
+func HTMLToText(src string, width int) string {
+	if width < minWidth {
+		width = minWidth
+	}
+
+	context := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(src), context)
+	if err != nil {
+		return src
+	}
+
+	r := &renderer{width: width}
+	for _, n := range nodes {
+		r.walk(n)
+	}
+	r.flush()
+
+	text := strings.TrimRight(r.out.String(), "\n")
+	if len(r.links) == 0 {
+		return text
+	}
+
+	var footnotes strings.Builder
+	footnotes.WriteString(text)
+	footnotes.WriteString("\n\n")
+	for i, link := range r.links {
+		fmt.Fprintf(&footnotes, "[%d] %s\n", i+1, link)
+	}
+	return strings.TrimRight(footnotes.String(), "\n")
+}
+
+func (r *renderer) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		if r.pre > 0 {
+			r.preText.WriteString(n.Data)
+		} else {
+			r.inline.WriteString(n.Data)
+		}
+		return
+	case html.ElementNode:
+	default:
+		r.children(n)
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Head, atom.Noscript, atom.Iframe:
+		return
+
+	case atom.Br:
+		if r.pre > 0 {
+			r.preText.WriteString("\n")
+			return
+		}
+		r.flush()
+
+	case atom.Hr:
+		r.block()
+		r.line(strings.Repeat("-", r.available()))
+		r.block()
+
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer, atom.Figure, atom.Figcaption,
+		atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Table, atom.Dl:
+		r.block()
+		r.children(n)
+		r.block()
+
+	case atom.Tr, atom.Dt, atom.Dd:
+		r.flush()
+		r.children(n)
+		r.flush()
+
+	case atom.Td, atom.Th:
+		r.inline.WriteString(" ")
+		r.children(n)
+		r.inline.WriteString(" ")
+
+	case atom.Blockquote:
+		r.block()
+		r.push("> ", "> ")
+		r.children(n)
+		r.flush()
+		r.pop()
+		r.block()
+
+	case atom.Ul, atom.Ol:
+		r.block()
+		r.lists = append(r.lists, &list{ordered: n.DataAtom == atom.Ol, next: startOf(n)})
+		r.children(n)
+		r.flush()
+		r.lists = r.lists[:len(r.lists)-1]
+		r.block()
+
+	case atom.Li:
+		r.flush()
+		marker := "* "
+		if len(r.lists) > 0 {
+			if l := r.lists[len(r.lists)-1]; l.ordered {
+				marker = strconv.Itoa(l.next) + ". "
+				l.next++
+			}
+		}
+		r.push(marker, strings.Repeat(" ", len(marker)))
+		r.children(n)
+		r.flush()
+		r.pop()
+
+	case atom.Pre:
+		r.block()
+		r.pre++
+		r.children(n)
+		r.pre--
+		if r.pre == 0 {
+			r.flushPre()
+		}
+		r.block()
+
+	case atom.Code, atom.Kbd, atom.Samp, atom.Tt:
+		if r.pre > 0 {
+			r.children(n)
+			return
+		}
+		r.inline.WriteString("`")
+		r.children(n)
+		r.inline.WriteString("`")
+
+	case atom.A:
+		r.children(n)
+		href := strings.TrimSpace(attr(n, "href"))
+		if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(href, "javascript:") {
+			return
+		}
+		r.links = append(r.links, href)
+		ref := fmt.Sprintf("[%d]", len(r.links))
+		if r.pre > 0 {
+			r.preText.WriteString(ref)
+		} else {
+			r.inline.WriteString(ref)
+		}
+
+	case atom.Img:
+		if alt := strings.TrimSpace(attr(n, "alt")); alt != "" {
+			r.inline.WriteString(" [image: " + alt + "] ")
+		}
+
+	default:
+		r.children(n)
+	}
+}
+
+func (r *renderer) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.walk(c)
+	}
+}
+
+// push starts a new nesting level, pop ends it.
+func (r *renderer) push(first, rest string) {
+	r.indents = append(r.indents, &indent{first: first, rest: rest})
+}
+
+func (r *renderer) pop() {
+	r.indents = r.indents[:len(r.indents)-1]
+}
+
+// prefix builds the indentation for the next line. Markers like "* " are only used once per item.
+func (r *renderer) prefix() string {
+	var b strings.Builder
+	for _, in := range r.indents {
+		if in.used {
+			b.WriteString(in.rest)
+			continue
+		}
+		b.WriteString(in.first)
+		in.used = true
+	}
+	return b.String()
+}
+
+func (r *renderer) restPrefix() string {
+	var b strings.Builder
+	for _, in := range r.indents {
+		b.WriteString(in.rest)
+	}
+	return b.String()
+}
+
+func (r *renderer) available() int {
+	avail := r.width - utf8.RuneCountInString(r.restPrefix())
+	if avail < minWidth {
+		return minWidth
+	}
+	return avail
+}
+
+func (r *renderer) line(text string) {
+	if r.pending {
+		r.out.WriteString(r.blank)
+		r.out.WriteString("\n")
+		r.pending = false
+	}
+	r.out.WriteString(r.prefix())
+	r.out.WriteString(text)
+	r.out.WriteString("\n")
+	r.started = true
+}
+
+/* block ends the current paragraph. The blank line between paragraphs is only written once the next
+line shows up, so it never appears at the very top, at the bottom, or twice in a row. When several
+blocks end at once (a <p> closing right before its <blockquote>) the outermost indentation wins. */
+
+func (r *renderer) block() {
+	r.flush()
+	if !r.started {
+		return
+	}
+	blank := strings.TrimRight(r.restPrefix(), " ")
+	if !r.pending || len(blank) < len(r.blank) {
+		r.blank = blank
+	}
+	r.pending = true
+}
+
+// flush word-wraps whatever inline text has been collected so far.
+func (r *renderer) flush() {
+	words := strings.Fields(r.inline.String())
+	r.inline.Reset()
+	if len(words) == 0 {
+		return
+	}
+
+	avail := r.available()
+	var current strings.Builder
+	length := 0
+	for _, word := range words {
+		wordLen := utf8.RuneCountInString(word)
+		if length > 0 && length+1+wordLen > avail {
+			r.line(current.String())
+			current.Reset()
+			length = 0
+		}
+		if length > 0 {
+			current.WriteString(" ")
+			length++
+		}
+		current.WriteString(word)
+		length += wordLen
+	}
+	r.line(current.String())
+}
+
+// flushPre writes a <pre> block line by line, indented four spaces and never wrapped.
+func (r *renderer) flushPre() {
+	text := strings.Trim(r.preText.String(), "\n")
+	r.preText.Reset()
+	text = strings.TrimRight(text, " \t\n")
+	if text == "" {
+		return
+	}
+	for _, l := range strings.Split(text, "\n") {
+		r.line(strings.TrimRight("    "+l, " \t"))
+	}
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func startOf(n *html.Node) int {
+	if start, err := strconv.Atoi(attr(n, "start")); err == nil {
+		return start
+	}
+	return 1
+}
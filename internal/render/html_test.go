@@ -0,0 +1,69 @@
+package render
+
+import "testing"
+
+func TestHTMLToText(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		width int
+		want  string
+	}{
+		{
+			name:  "paragraphs wrap at the width",
+			in:    "<p>one two three four five six seven eight nine ten eleven</p><p>next</p>",
+			width: 20,
+			want:  "one two three four\nfive six seven eight\nnine ten eleven\n\nnext",
+		},
+		{
+			name:  "tiny widths use the minimum",
+			in:    "<p>one two three four five</p>",
+			width: 5,
+			want:  "one two three four\nfive",
+		},
+		{
+			name:  "nested lists",
+			in:    "<ul><li>fruit<ul><li>apple</li><li>pear</li></ul></li><li>bread</li></ul><ol start=\"3\"><li>three</li><li>four</li></ol>",
+			width: 40,
+			want:  "* fruit\n\n  * apple\n  * pear\n\n* bread\n\n3. three\n4. four",
+		},
+		{
+			name:  "blockquotes",
+			in:    "<p>He said:</p><blockquote><p>first</p><p>second</p></blockquote><p>after</p>",
+			width: 40,
+			want:  "He said:\n\n> first\n>\n> second\n\nafter",
+		},
+		{
+			name:  "links become footnotes",
+			in:    `<p>See <a href="https://go.dev">Go</a>, <a href="#top">top</a> and <a href="https://pkg.go.dev">docs</a>.</p>`,
+			width: 40,
+			want:  "See Go[1], top and docs[2].\n\n[1] https://go.dev\n[2] https://pkg.go.dev",
+		},
+		{
+			name:  "pre is kept verbatim",
+			in:    "<p>Code:</p><pre><code>func main() {\n\tfmt.Println(\"hi\")   \n}\n</code></pre><p>done</p>",
+			width: 20,
+			want:  "Code:\n\n    func main() {\n    \tfmt.Println(\"hi\")\n    }\n\ndone",
+		},
+		{
+			name:  "entities are decoded",
+			in:    "<p>Tom &amp; Jerry &lt;3 caf&eacute; &#8212; &quot;hi&quot;</p>",
+			width: 40,
+			want:  "Tom & Jerry <3 café — \"hi\"",
+		},
+		{
+			name:  "scripts and styles are dropped",
+			in:    "<style>p{}</style><p>text<script>alert(1)</script></p>",
+			width: 40,
+			want:  "text",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HTMLToText(tt.in, tt.width); got != tt.want {
+				t.Errorf("HTMLToText() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
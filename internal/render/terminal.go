@@ -0,0 +1,29 @@
+package render
+
+import (
+	"os"
+	"strconv"
+
+	"golang.org/x/term"
+)
+
+const defaultWidth = 80
+
+// TerminalWidth returns how many columns stdout has. If stdout isn't a terminal (piped to a file, etc.)
+// it falls back to $COLUMNS and then to 80.
+
+func TerminalWidth() int {
+	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && width > 0 {
+		return width
+	}
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	return defaultWidth
+}
+
+// IsTerminal tells whether stdout is a terminal, where escape codes like bold show up as intended
+
+func IsTerminal() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
@@ -0,0 +1,261 @@
+package resolve
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	rss "github.com/azhagan2/blog_aggregator/internal/RSS"
+)
+
+/* People paste the page they look at (a YouTube channel, a subreddit, a GitHub repo), not its feed.
+The resolver recognizes those URL shapes and rewrites them to the platform's official feed endpoint.
+Anything it doesn't recognize comes back unchanged, so a normal feed URL just passes through. */
+
+type Resolver struct {
+	// Fetch downloads a page, needed for YouTube @handles whose channel id isn't in the URL and to ask
+	// a server whether it runs Mastodon
+	Fetch func(ctx context.Context, pageURL string) ([]byte, error)
+}
+
+// rule is one platform: match says if a URL belongs to it, rewrite returns the feed URL
+type rule struct {
+	name    string
+	match   func(u *url.URL) bool
+	rewrite func(ctx context.Context, r *Resolver, u *url.URL) (string, error)
+}
+
+var rules = []rule{
+	{name: "youtube", match: isYouTube, rewrite: youTubeFeed},
+	{name: "reddit", match: isReddit, rewrite: redditFeed},
+	{name: "github", match: isGitHub, rewrite: gitHubFeed},
+	{name: "mastodon", match: isMastodon, rewrite: mastodonFeed},
+}
+
+var defaultResolver = &Resolver{Fetch: rss.FetchPage}
+
+// FeedURL resolves raw with the default resolver, which uses the normal fetcher for lookups
+
+func FeedURL(ctx context.Context, raw string) (string, error) {
+	return defaultResolver.FeedURL(ctx, raw)
+}
+
+func (r *Resolver) FeedURL(ctx context.Context, raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	withScheme := raw
+	if !strings.Contains(raw, "://") {
+		withScheme = "https://" + raw
+	}
+
+	u, err := url.Parse(withScheme)
+	if err != nil || u.Host == "" {
+		return raw, nil
+	}
+	u.Host = strings.ToLower(u.Host)
+
+	for _, rule := range rules {
+		if !rule.match(u) {
+			continue
+		}
+		feed, err := rule.rewrite(ctx, r, u)
+		if err != nil {
+			return "", fmt.Errorf("couldn't resolve %s url: %w", rule.name, err)
+		}
+		if feed != "" {
+			return feed, nil
+		}
+	}
+	return raw, nil
+}
+
+func hostIs(u *url.URL, domains ...string) bool {
+	host := strings.TrimPrefix(u.Host, "www.")
+	for _, d := range domains {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// segments splits the path, "/r/golang/" -> ["r", "golang"]
+func segments(u *url.URL) []string {
+	var parts []string
+	for _, p := range strings.Split(u.Path, "/") {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
+
+// YouTube: channel, user and playlist pages all have Atom feeds under /feeds/videos.xml
+
+const youTubeFeedURL = "https://www.youtube.com/feeds/videos.xml"
+
+var youTubeChannelID = regexp.MustCompile(`(?:feeds/videos\.xml\?channel_id=|"(?:channelId|externalId)":")(UC[A-Za-z0-9_-]{22})`)
+
+func isYouTube(u *url.URL) bool {
+	return hostIs(u, "youtube.com", "youtu.be")
+}
+
+func youTubeFeed(ctx context.Context, r *Resolver, u *url.URL) (string, error) {
+	parts := segments(u)
+	if len(parts) == 0 {
+		return "", nil
+	}
+
+	switch {
+	case parts[0] == "feeds":
+		return "", nil
+	case parts[0] == "playlist" && u.Query().Get("list") != "":
+		return youTubeFeedURL + "?playlist_id=" + url.QueryEscape(u.Query().Get("list")), nil
+	case parts[0] == "channel" && len(parts) > 1:
+		return youTubeFeedURL + "?channel_id=" + url.QueryEscape(parts[1]), nil
+	case parts[0] == "user" && len(parts) > 1:
+		return youTubeFeedURL + "?user=" + url.QueryEscape(parts[1]), nil
+	case strings.HasPrefix(parts[0], "@"), parts[0] == "c" && len(parts) > 1:
+		// handles and custom urls don't contain the channel id, the channel page does
+		page := "https://www.youtube.com/" + parts[0]
+		if parts[0] == "c" {
+			page += "/" + parts[1]
+		}
+		if r.Fetch == nil {
+			return "", fmt.Errorf("no fetcher to look up %s", page)
+		}
+		data, err := r.Fetch(ctx, page)
+		if err != nil {
+			return "", err
+		}
+		match := youTubeChannelID.FindSubmatch(data)
+		if match == nil {
+			return "", fmt.Errorf("no channel id found on %s", page)
+		}
+		return youTubeFeedURL + "?channel_id=" + string(match[1]), nil
+	}
+	return "", nil
+}
+
+// Reddit: any listing gets an RSS version by adding /.rss to the path
+
+func isReddit(u *url.URL) bool {
+	return hostIs(u, "reddit.com")
+}
+
+func redditFeed(ctx context.Context, r *Resolver, u *url.URL) (string, error) {
+	parts := segments(u)
+	if len(parts) == 0 {
+		return "https://www.reddit.com/.rss", nil
+	}
+	if strings.HasSuffix(parts[len(parts)-1], ".rss") {
+		return "", nil
+	}
+	switch parts[0] {
+	case "r", "user", "u":
+	default:
+		return "", nil
+	}
+
+	feed := url.URL{Scheme: "https", Host: "www.reddit.com", Path: "/" + strings.Join(parts, "/") + "/.rss", RawQuery: u.RawQuery}
+	return feed.String(), nil
+}
+
+// GitHub: a repo means its releases, /commits/{branch} its commits, a bare user their public activity
+
+func isGitHub(u *url.URL) bool {
+	return u.Host == "github.com" || u.Host == "www.github.com"
+}
+
+func gitHubFeed(ctx context.Context, r *Resolver, u *url.URL) (string, error) {
+	parts := segments(u)
+	if len(parts) == 0 || strings.HasSuffix(parts[len(parts)-1], ".atom") {
+		return "", nil
+	}
+
+	base := "https://github.com/"
+	if len(parts) == 1 {
+		return base + parts[0] + ".atom", nil
+	}
+
+	repo := base + parts[0] + "/" + strings.TrimSuffix(parts[1], ".git")
+	if len(parts) == 2 {
+		return repo + "/releases.atom", nil
+	}
+
+	switch parts[2] {
+	case "releases":
+		return repo + "/releases.atom", nil
+	case "tags":
+		return repo + "/tags.atom", nil
+	case "commits":
+		if len(parts) > 3 {
+			return repo + "/commits/" + strings.Join(parts[3:], "/") + ".atom", nil
+		}
+		return repo + "/commits.atom", nil
+	}
+	return repo + "/releases.atom", nil
+}
+
+/* Mastodon: any instance can host it, and plenty of other sites use /@user paths too (TikTok, Threads,
+Medium), so the shape of the path only makes a candidate. The server has to say it runs Mastodon in its
+nodeinfo (/.well-known/nodeinfo) before /@user becomes /@user.rss, anything else is left as it is. */
+
+var mastodonUser = regexp.MustCompile(`^/@([A-Za-z0-9_]+)/?$`)
+
+// mastodonSoftware are the nodeinfo software names that serve /@user.rss
+var mastodonSoftware = map[string]bool{"mastodon": true, "hometown": true}
+
+func isMastodon(u *url.URL) bool {
+	return mastodonUser.MatchString(u.Path) && !hostIs(u, "medium.com", "youtube.com")
+}
+
+func mastodonFeed(ctx context.Context, r *Resolver, u *url.URL) (string, error) {
+	if !runsMastodon(ctx, r, u.Host) {
+		return "", nil
+	}
+	user := mastodonUser.FindStringSubmatch(u.Path)[1]
+	return "https://" + u.Host + "/@" + user + ".rss", nil
+}
+
+// runsMastodon asks the server's nodeinfo what software it runs, a server without nodeinfo isn't Mastodon
+func runsMastodon(ctx context.Context, r *Resolver, host string) bool {
+	if r.Fetch == nil {
+		return false
+	}
+	data, err := r.Fetch(ctx, "https://"+host+"/.well-known/nodeinfo")
+	if err != nil {
+		return false
+	}
+	var index struct {
+		Links []struct {
+			Rel  string `json:"rel"`
+			Href string `json:"href"`
+		} `json:"links"`
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return false
+	}
+
+	for _, link := range index.Links {
+		if !strings.HasPrefix(link.Rel, "http://nodeinfo.diaspora.software/ns/schema/") {
+			continue
+		}
+		data, err := r.Fetch(ctx, link.Href)
+		if err != nil {
+			return false
+		}
+		var info struct {
+			Software struct {
+				Name string `json:"name"`
+			} `json:"software"`
+		}
+		if err := json.Unmarshal(data, &info); err != nil {
+			return false
+		}
+		return mastodonSoftware[strings.ToLower(info.Software.Name)]
+	}
+	return false
+}
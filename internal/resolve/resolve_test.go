@@ -0,0 +1,89 @@
+package resolve
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFeedURL(t *testing.T) {
+	channelPage := []byte(`<link rel="alternate" type="application/rss+xml" title="RSS" href="https://www.youtube.com/feeds/videos.xml?channel_id=UCxxxxxxxxxxxxxxxxxxxxxA">`)
+	pages := map[string]string{
+		"https://mastodon.social/.well-known/nodeinfo": `{"links":[{"rel":"http://nodeinfo.diaspora.software/ns/schema/2.0","href":"https://mastodon.social/nodeinfo/2.0"}]}`,
+		"https://mastodon.social/nodeinfo/2.0":         `{"version":"2.0","software":{"name":"mastodon","version":"4.3.0"}}`,
+		"https://hachyderm.io/.well-known/nodeinfo":    `{"links":[{"rel":"http://nodeinfo.diaspora.software/ns/schema/2.1","href":"https://hachyderm.io/nodeinfo/2.1"}]}`,
+		"https://hachyderm.io/nodeinfo/2.1":            `{"version":"2.1","software":{"name":"Mastodon"}}`,
+		"https://misskey.io/.well-known/nodeinfo":      `{"links":[{"rel":"http://nodeinfo.diaspora.software/ns/schema/2.0","href":"https://misskey.io/nodeinfo/2.0"}]}`,
+		"https://misskey.io/nodeinfo/2.0":              `{"version":"2.0","software":{"name":"misskey"}}`,
+		"https://www.threads.net/.well-known/nodeinfo": `<html>not json</html>`,
+	}
+	r := &Resolver{Fetch: func(ctx context.Context, pageURL string) ([]byte, error) {
+		if pageURL == "https://www.youtube.com/@gophers" || pageURL == "https://www.youtube.com/c/gophers" {
+			return channelPage, nil
+		}
+		if page, ok := pages[pageURL]; ok {
+			return []byte(page), nil
+		}
+		return nil, errors.New("404 fetching " + pageURL)
+	}}
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"youtube handle", "youtube.com/@gophers", "https://www.youtube.com/feeds/videos.xml?channel_id=UCxxxxxxxxxxxxxxxxxxxxxA"},
+		{"youtube handle with tab", "https://www.youtube.com/@gophers/videos", "https://www.youtube.com/feeds/videos.xml?channel_id=UCxxxxxxxxxxxxxxxxxxxxxA"},
+		{"youtube custom url", "https://www.youtube.com/c/gophers", "https://www.youtube.com/feeds/videos.xml?channel_id=UCxxxxxxxxxxxxxxxxxxxxxA"},
+		{"youtube channel id", "https://www.youtube.com/channel/UCyyyyyyyyyyyyyyyyyyyyyB", "https://www.youtube.com/feeds/videos.xml?channel_id=UCyyyyyyyyyyyyyyyyyyyyyB"},
+		{"youtube user", "https://youtube.com/user/golang", "https://www.youtube.com/feeds/videos.xml?user=golang"},
+		{"youtube playlist", "https://www.youtube.com/playlist?list=PL123", "https://www.youtube.com/feeds/videos.xml?playlist_id=PL123"},
+		{"youtube feed", "https://www.youtube.com/feeds/videos.xml?channel_id=UCz", "https://www.youtube.com/feeds/videos.xml?channel_id=UCz"},
+
+		{"reddit subreddit", "reddit.com/r/golang", "https://www.reddit.com/r/golang/.rss"},
+		{"reddit trailing slash", "https://old.reddit.com/r/golang/", "https://www.reddit.com/r/golang/.rss"},
+		{"reddit sorted", "https://www.reddit.com/r/golang/top?t=week", "https://www.reddit.com/r/golang/top/.rss?t=week"},
+		{"reddit user", "https://www.reddit.com/user/spez", "https://www.reddit.com/user/spez/.rss"},
+		{"reddit feed", "https://www.reddit.com/r/golang/.rss", "https://www.reddit.com/r/golang/.rss"},
+
+		{"mastodon user", "mastodon.social/@gopher", "https://mastodon.social/@gopher.rss"},
+		{"mastodon other instance", "https://hachyderm.io/@gopher/", "https://hachyderm.io/@gopher.rss"},
+		{"mastodon feed", "https://mastodon.social/@gopher.rss", "https://mastodon.social/@gopher.rss"},
+		{"medium is not mastodon", "https://medium.com/@gopher", "https://medium.com/@gopher"},
+		{"tiktok is not mastodon", "https://www.tiktok.com/@gopher", "https://www.tiktok.com/@gopher"},
+		{"threads is not mastodon", "https://www.threads.net/@gopher", "https://www.threads.net/@gopher"},
+		{"other fediverse software", "https://misskey.io/@gopher", "https://misskey.io/@gopher"},
+
+		{"github repo", "github.com/golang/go", "https://github.com/golang/go/releases.atom"},
+		{"github repo .git", "https://github.com/golang/go.git", "https://github.com/golang/go/releases.atom"},
+		{"github releases", "https://github.com/golang/go/releases", "https://github.com/golang/go/releases.atom"},
+		{"github tags", "https://github.com/golang/go/tags", "https://github.com/golang/go/tags.atom"},
+		{"github commits", "https://github.com/golang/go/commits/master", "https://github.com/golang/go/commits/master.atom"},
+		{"github user", "https://github.com/rsc", "https://github.com/rsc.atom"},
+		{"github feed", "https://github.com/golang/go/releases.atom", "https://github.com/golang/go/releases.atom"},
+
+		{"plain feed", "https://blog.boot.dev/index.xml", "https://blog.boot.dev/index.xml"},
+		{"plain feed no scheme", "blog.boot.dev/index.xml", "blog.boot.dev/index.xml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.FeedURL(context.Background(), tt.in)
+			if err != nil {
+				t.Fatalf("FeedURL(%q) error: %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("FeedURL(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeedURLYouTubeHandleWithoutChannelID(t *testing.T) {
+	r := &Resolver{Fetch: func(ctx context.Context, pageURL string) ([]byte, error) {
+		return []byte("<html>nothing here</html>"), nil
+	}}
+	if _, err := r.FeedURL(context.Background(), "https://www.youtube.com/@nobody"); err == nil {
+		t.Fatal("expected an error when the channel page has no channel id")
+	}
+}
@@ -0,0 +1,172 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+/* Parse reads a search the way search boxes usually work, and writes it out for the database:
+
+	go generics        posts with both words
+	"error handling"   the exact phrase
+	gorout*            words starting with gorout
+	-java              posts without java
+	rust OR zig        either one
+
+Words are cut at anything that isn't a letter or a digit (so "c++" is just "c"), which also means
+nothing typed can break the tsquery or FTS5 syntax it's turned into. */
+
+// Term is one word or phrase of a search, a phrase is more than one word in that order
+type Term struct {
+	Words   []string
+	Prefix  bool
+	Exclude bool
+}
+
+// Query matches a post when any of its groups does, and a group when all of its terms do
+type Query struct {
+	Groups [][]Term
+}
+
+var ErrEmpty = errors.New("nothing to search for")
+
+func Parse(input string) (Query, error) {
+	var q Query
+	var group []Term
+	endGroup := func() {
+		if len(group) > 0 {
+			q.Groups = append(q.Groups, group)
+		}
+		group = nil
+	}
+
+	rest := []rune(input)
+	for len(rest) > 0 {
+		if unicode.IsSpace(rest[0]) {
+			rest = rest[1:]
+			continue
+		}
+
+		var term Term
+		if rest[0] == '-' {
+			term.Exclude = true
+			rest = rest[1:]
+		}
+
+		var text string
+		if len(rest) > 0 && rest[0] == '"' {
+			// a phrase runs to the closing quote, or to the end if there's none
+			end := 1
+			for end < len(rest) && rest[end] != '"' {
+				end++
+			}
+			text = string(rest[1:end])
+			rest = rest[min(end+1, len(rest)):]
+			if len(rest) > 0 && rest[0] == '*' {
+				term.Prefix = true
+				rest = rest[1:]
+			}
+		} else {
+			end := 0
+			for end < len(rest) && !unicode.IsSpace(rest[end]) && rest[end] != '"' {
+				end++
+			}
+			text = string(rest[:end])
+			rest = rest[end:]
+			if text == "OR" && !term.Exclude {
+				endGroup()
+				continue
+			}
+			if strings.HasSuffix(text, "*") {
+				term.Prefix = true
+			}
+		}
+
+		term.Words = words(text)
+		if len(term.Words) > 0 {
+			group = append(group, term)
+		}
+	}
+	endGroup()
+
+	if len(q.Groups) == 0 {
+		return Query{}, ErrEmpty
+	}
+	for _, group := range q.Groups {
+		if !hasIncluded(group) {
+			return Query{}, fmt.Errorf("%q only says what to leave out, add a word to look for", input)
+		}
+	}
+	return q, nil
+}
+
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func hasIncluded(group []Term) bool {
+	for _, term := range group {
+		if !term.Exclude {
+			return true
+		}
+	}
+	return false
+}
+
+// TSQuery is the query for PostgreSQL's to_tsquery: & for and, | for or, ! for not, <-> for a phrase, :* for a prefix
+func (q Query) TSQuery() string {
+	groups := make([]string, len(q.Groups))
+	for i, group := range q.Groups {
+		terms := make([]string, len(group))
+		for j, term := range group {
+			t := strings.Join(term.Words, " <-> ")
+			if term.Prefix {
+				t += ":*"
+			}
+			if len(term.Words) > 1 {
+				t = "(" + t + ")"
+			}
+			if term.Exclude {
+				t = "!" + t
+			}
+			terms[j] = t
+		}
+		groups[i] = strings.Join(terms, " & ")
+		if len(q.Groups) > 1 && len(group) > 1 {
+			groups[i] = "(" + groups[i] + ")"
+		}
+	}
+	return strings.Join(groups, " | ")
+}
+
+// FTS5 is the query for SQLite's MATCH. NOT there only goes between two terms, so the excluded ones come last in each group.
+func (q Query) FTS5() string {
+	groups := make([]string, len(q.Groups))
+	for i, group := range q.Groups {
+		var included, excluded []string
+		for _, term := range group {
+			t := `"` + strings.Join(term.Words, " ") + `"`
+			if term.Prefix {
+				t += "*"
+			}
+			if term.Exclude {
+				excluded = append(excluded, t)
+			} else {
+				included = append(included, t)
+			}
+		}
+		g := strings.Join(included, " AND ")
+		for _, t := range excluded {
+			g += " NOT " + t
+		}
+		if len(q.Groups) > 1 && len(group) > 1 {
+			g = "(" + g + ")"
+		}
+		groups[i] = g
+	}
+	return strings.Join(groups, " OR ")
+}
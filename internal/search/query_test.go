@@ -0,0 +1,57 @@
+package search
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		tsquery string
+		fts5    string
+	}{
+		{"golang", "golang", `"golang"`},
+		{"Go Generics", "go & generics", `"go" AND "generics"`},
+		{`"error handling" go`, "(error <-> handling) & go", `"error handling" AND "go"`},
+		{"gorout*", "gorout:*", `"gorout"*`},
+		{`"worker pool"*`, "(worker <-> pool:*)", `"worker pool"*`},
+		{"-java go", "!java & go", `"go" NOT "java"`},
+		{`go -"hello world"`, "go & !(hello <-> world)", `"go" NOT "hello world"`},
+		{"rust OR zig", "rust | zig", `"rust" OR "zig"`},
+		{"rust async OR zig", "(rust & async) | zig", `("rust" AND "async") OR "zig"`},
+		{"OR rust OR OR zig OR", "rust | zig", `"rust" OR "zig"`},
+		{"c++ it's", "c & (it <-> s)", `"c" AND "it s"`},
+		{`"unclosed phrase`, "(unclosed <-> phrase)", `"unclosed phrase"`},
+		{"café naïve", "café & naïve", `"café" AND "naïve"`},
+		{"'; DROP TABLE posts; --", "drop & table & posts", `"drop" AND "table" AND "posts"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			q, err := Parse(tt.in)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.in, err)
+			}
+			if got := q.TSQuery(); got != tt.tsquery {
+				t.Errorf("TSQuery() = %q, want %q", got, tt.tsquery)
+			}
+			if got := q.FTS5(); got != tt.fts5 {
+				t.Errorf("FTS5() = %q, want %q", got, tt.fts5)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, in := range []string{"", "   ", "OR", "-- !!", `""`} {
+		if _, err := Parse(in); !errors.Is(err, ErrEmpty) {
+			t.Errorf("Parse(%q) = %v, want ErrEmpty", in, err)
+		}
+	}
+	for _, in := range []string{"-java", "go OR -java"} {
+		if _, err := Parse(in); err == nil || errors.Is(err, ErrEmpty) {
+			t.Errorf("Parse(%q) = %v, want an error about only excluding", in, err)
+		}
+	}
+}
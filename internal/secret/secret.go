@@ -0,0 +1,92 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+/* Feed credentials are stored encrypted with AES-256-GCM, so a dump of the database doesn't hand out
+everyone's passwords and tokens. The key lives in the config file (secret_key), as base64 of 32 random
+bytes. Ciphertexts are base64 of nonce + sealed data, so they fit in a TEXT column. */
+
+const keySize = 32
+
+var ErrNoKey = errors.New("no secret_key in the config file")
+
+// NewKey makes a fresh random key, already base64 encoded for the config file
+
+func NewKey() (string, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("error generating secret key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+func parseKey(encoded string) ([]byte, error) {
+	if encoded == "" {
+		return nil, ErrNoKey
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("secret_key is not valid base64: %w", err)
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("secret_key must be %d bytes, got %d", keySize, len(key))
+	}
+	return key, nil
+}
+
+func newGCM(encodedKey string) (cipher.AEAD, error) {
+	key, err := parseKey(encodedKey)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func Encrypt(encodedKey string, plaintext []byte) (string, error) {
+	gcm, err := newGCM(encodedKey)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("error generating nonce: %w", err)
+	}
+
+	sealed := gcm.Seal(nonce, nonce, plaintext, nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func Decrypt(encodedKey string, ciphertext string) ([]byte, error) {
+	gcm, err := newGCM(encodedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("stored credentials are not valid base64: %w", err)
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("stored credentials are too short")
+	}
+
+	nonce, sealed := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		// wrong key (the config's secret_key changed) or tampered data
+		return nil, fmt.Errorf("couldn't decrypt stored credentials: %w", err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,71 @@
+package secret
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	key, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := []byte(`{"token":"hunter2"}`)
+
+	ciphertext, err := Encrypt(key, plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(ciphertext, "hunter2") {
+		t.Fatalf("ciphertext %q has the plaintext in it", ciphertext)
+	}
+	again, err := Encrypt(key, plaintext)
+	if err != nil || again == ciphertext {
+		t.Errorf("encrypting twice gave the same ciphertext, the nonce should differ (%v)", err)
+	}
+
+	got, err := Decrypt(key, ciphertext)
+	if err != nil || string(got) != string(plaintext) {
+		t.Errorf("Decrypt = %q, %v", got, err)
+	}
+}
+
+func TestDecryptFails(t *testing.T) {
+	key, _ := NewKey()
+	otherKey, _ := NewKey()
+	ciphertext, err := Encrypt(key, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := base64.StdEncoding.DecodeString(ciphertext)
+	data[len(data)-1] ^= 1
+	tampered := base64.StdEncoding.EncodeToString(data)
+
+	tests := []struct {
+		name       string
+		key        string
+		ciphertext string
+	}{
+		{"wrong key", otherKey, ciphertext},
+		{"tampered", key, tampered},
+		{"not base64", key, "%%%"},
+		{"too short", key, base64.StdEncoding.EncodeToString([]byte("abc"))},
+		{"key too short", base64.StdEncoding.EncodeToString([]byte("short")), ciphertext},
+		{"key not base64", "not a key!", ciphertext},
+	}
+	for _, tt := range tests {
+		if got, err := Decrypt(tt.key, tt.ciphertext); err == nil {
+			t.Errorf("%s: Decrypt = %q, want an error", tt.name, got)
+		}
+	}
+
+	if _, err := Decrypt("", ciphertext); !errors.Is(err, ErrNoKey) {
+		t.Errorf("without a key err = %v, want ErrNoKey", err)
+	}
+	if _, err := Encrypt("", []byte("x")); !errors.Is(err, ErrNoKey) {
+		t.Errorf("encrypting without a key err = %v, want ErrNoKey", err)
+	}
+}
@@ -3,19 +3,24 @@ package state
 import (
 	"github.com/azhagan2/blog_aggregator/internal/config"
 	"github.com/azhagan2/blog_aggregator/internal/database"
+	"github.com/azhagan2/blog_aggregator/internal/storage"
 )
 
 type State struct {
-	Db  *database.Queries
+	// Db is the sqlc queries on the real database, or anything else that answers them (memory.DB in tests)
+	Db  database.Store
 	Cfg *config.Config
+	// Conn is the database the queries run on, for what sqlc doesn't cover (migrations)
+	Conn *storage.DB
 }
 
 // constructor
 
-func New(cfg *config.Config, dbQueries *database.Queries) *State {
+func New(cfg *config.Config, conn *storage.DB, dbQueries database.Store) *State {
 	return &State{
-		Cfg: cfg,
-		Db:  dbQueries,
+		Cfg:  cfg,
+		Db:   dbQueries,
+		Conn: conn,
 	}
 }
 
@@ -0,0 +1,1030 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/azhagan2/blog_aggregator/internal/database"
+	"github.com/azhagan2/blog_aggregator/internal/search"
+)
+
+/* DB keeps everything in memory and answers the same queries as the real database, for tests of the
+command handlers without a PostgreSQL server. It follows what the SQL does where the handlers can tell:
+sql.ErrNoRows for a missing row, a "UNIQUE constraint" error for a duplicate, ON DELETE CASCADE, and the
+ordering of each query. Rows are copied in and out, so changing a returned value changes nothing stored. */
+
+type DB struct {
+	mu      sync.Mutex
+	users   []database.User
+	feeds   []database.Feed
+	follows []database.FeedFollow
+	posts   []database.Post
+	subs    []database.WebsubSubscription
+	reads   []database.PostRead
+	saved   []database.Bookmark
+	tags    []database.BookmarkTag
+}
+
+var _ database.Store = (*DB)(nil)
+
+func New() *DB {
+	return &DB{}
+}
+
+func duplicate(constraint string) error {
+	return fmt.Errorf("UNIQUE constraint failed: %s", constraint)
+}
+
+/* InTx runs fn on the same DB and puts every row back the way it was if fn fails. Unlike a real
+transaction nothing is isolated, which is fine for tests that don't run two at once. */
+
+func (db *DB) InTx(ctx context.Context, fn func(q database.Store) error) error {
+	db.mu.Lock()
+	users := append([]database.User(nil), db.users...)
+	feeds := append([]database.Feed(nil), db.feeds...)
+	follows := append([]database.FeedFollow(nil), db.follows...)
+	posts := append([]database.Post(nil), db.posts...)
+	subs := append([]database.WebsubSubscription(nil), db.subs...)
+	reads := append([]database.PostRead(nil), db.reads...)
+	saved := append([]database.Bookmark(nil), db.saved...)
+	tags := append([]database.BookmarkTag(nil), db.tags...)
+	db.mu.Unlock()
+
+	if err := fn(db); err != nil {
+		db.mu.Lock()
+		db.users, db.feeds, db.follows, db.posts, db.subs, db.reads = users, feeds, follows, posts, subs, reads
+		db.saved, db.tags = saved, tags
+		db.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Users
+
+func (db *DB) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for _, u := range db.users {
+		if u.ID == arg.ID {
+			return database.User{}, duplicate("users.id")
+		}
+		if u.Name == arg.Name {
+			return database.User{}, duplicate("users.name")
+		}
+	}
+	user := database.User{ID: arg.ID, CreatedAt: arg.CreatedAt, UpdatedAt: arg.UpdatedAt, Name: arg.Name}
+	db.users = append(db.users, user)
+	return user, nil
+}
+
+func (db *DB) GetUser(ctx context.Context, name string) (database.User, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for _, u := range db.users {
+		if u.Name == name {
+			return u, nil
+		}
+	}
+	return database.User{}, sql.ErrNoRows
+}
+
+func (db *DB) GetUserById(ctx context.Context, id uuid.UUID) (string, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for _, u := range db.users {
+		if u.ID == id {
+			return u.Name, nil
+		}
+	}
+	return "", sql.ErrNoRows
+}
+
+func (db *DB) GetUsers(ctx context.Context) ([]string, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var names []string
+	for _, u := range db.users {
+		names = append(names, u.Name)
+	}
+	return names, nil
+}
+
+// DeleteUser deletes every user, and with them (ON DELETE CASCADE) their feeds, follows, and the feeds' posts and subscriptions
+func (db *DB) DeleteUser(ctx context.Context) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.users = nil
+	db.follows = nil
+	db.reads = nil
+	db.saved = nil
+	db.tags = nil
+	var feeds []database.Feed
+	for _, f := range db.feeds {
+		if !f.UserID.Valid {
+			feeds = append(feeds, f)
+		}
+	}
+	db.feeds = feeds
+	db.dropOrphans()
+	return nil
+}
+
+// dropOrphans is ON DELETE CASCADE for the rows that point at a feed that's gone
+func (db *DB) dropOrphans() {
+	exists := map[uuid.UUID]bool{}
+	for _, f := range db.feeds {
+		exists[f.ID] = true
+	}
+
+	var posts []database.Post
+	for _, p := range db.posts {
+		if !p.FeedID.Valid || exists[p.FeedID.UUID] {
+			posts = append(posts, p)
+		}
+	}
+	db.posts = posts
+
+	var follows []database.FeedFollow
+	for _, f := range db.follows {
+		if !f.FeedID.Valid || exists[f.FeedID.UUID] {
+			follows = append(follows, f)
+		}
+	}
+	db.follows = follows
+
+	var subs []database.WebsubSubscription
+	for _, s := range db.subs {
+		if exists[s.FeedID] {
+			subs = append(subs, s)
+		}
+	}
+	db.subs = subs
+
+	stored := map[uuid.UUID]bool{}
+	for _, p := range db.posts {
+		stored[p.ID] = true
+	}
+	var reads []database.PostRead
+	for _, r := range db.reads {
+		if stored[r.PostID] {
+			reads = append(reads, r)
+		}
+	}
+	db.reads = reads
+
+	// a bookmark keeps its copy of the post (ON DELETE SET NULL)
+	for i, b := range db.saved {
+		if b.PostID.Valid && !stored[b.PostID.UUID] {
+			db.saved[i].PostID = uuid.NullUUID{}
+		}
+	}
+}
+
+// Feeds
+
+func (db *DB) CreateFeed(ctx context.Context, arg database.CreateFeedParams) (database.Feed, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for _, f := range db.feeds {
+		switch {
+		case f.ID == arg.ID:
+			return database.Feed{}, duplicate("feeds.id")
+		case f.Name == arg.Name:
+			return database.Feed{}, duplicate("feeds.name")
+		case f.Url == arg.Url:
+			return database.Feed{}, duplicate("feeds.url")
+		}
+	}
+	feed := database.Feed{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		Name:      arg.Name,
+		Url:       arg.Url,
+		UserID:    arg.UserID,
+		Kind:      "rss",
+	}
+	db.feeds = append(db.feeds, feed)
+	return feed, nil
+}
+
+func (db *DB) findFeed(match func(f database.Feed) bool) (database.Feed, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for _, f := range db.feeds {
+		if match(f) {
+			return f, nil
+		}
+	}
+	return database.Feed{}, sql.ErrNoRows
+}
+
+func (db *DB) GetFeedByID(ctx context.Context, id uuid.UUID) (database.Feed, error) {
+	return db.findFeed(func(f database.Feed) bool { return f.ID == id })
+}
+
+func (db *DB) GetFeedByName(ctx context.Context, name string) (database.Feed, error) {
+	return db.findFeed(func(f database.Feed) bool { return f.Name == name })
+}
+
+func (db *DB) GetFeed_ByURL(ctx context.Context, url string) (database.Feed, error) {
+	return db.findFeed(func(f database.Feed) bool { return f.Url == url })
+}
+
+func (db *DB) GetFeeds(ctx context.Context) ([]database.Feed, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return append([]database.Feed(nil), db.feeds...), nil
+}
+
+// Get_Next_Feed_to_fetch is ORDER BY last_fetched_at NULLS FIRST: never fetched first, then the longest ago
+func (db *DB) Get_Next_Feed_to_fetch(ctx context.Context) (database.Feed, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if len(db.feeds) == 0 {
+		return database.Feed{}, sql.ErrNoRows
+	}
+	feeds := append([]database.Feed(nil), db.feeds...)
+	sort.SliceStable(feeds, func(i, j int) bool {
+		a, b := feeds[i].LastFetchedAt, feeds[j].LastFetchedAt
+		if !a.Valid || !b.Valid {
+			return !a.Valid && b.Valid
+		}
+		return a.Time.Before(b.Time)
+	})
+	return feeds[0], nil
+}
+
+// updateFeed changes the feed with id in place, a missing feed is no error (UPDATE of no rows)
+func (db *DB) updateFeed(id uuid.UUID, change func(f *database.Feed)) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for i := range db.feeds {
+		if db.feeds[i].ID == id {
+			change(&db.feeds[i])
+		}
+	}
+	return nil
+}
+
+func (db *DB) Mark_Feed_Fetched(ctx context.Context, id uuid.UUID) error {
+	now := time.Now()
+	return db.updateFeed(id, func(f *database.Feed) {
+		f.UpdatedAt = now
+		f.LastFetchedAt = sql.NullTime{Time: now, Valid: true}
+	})
+}
+
+func (db *DB) SetFeedCredentials(ctx context.Context, arg database.SetFeedCredentialsParams) error {
+	return db.updateFeed(arg.ID, func(f *database.Feed) {
+		f.Credentials = arg.Credentials
+		f.UpdatedAt = time.Now()
+	})
+}
+
+func (db *DB) SetFeedFavicon(ctx context.Context, arg database.SetFeedFaviconParams) error {
+	return db.updateFeed(arg.ID, func(f *database.Feed) {
+		f.FaviconPath = arg.FaviconPath
+		f.UpdatedAt = time.Now()
+	})
+}
+
+func (db *DB) SetFeedFullText(ctx context.Context, arg database.SetFeedFullTextParams) error {
+	return db.updateFeed(arg.ID, func(f *database.Feed) {
+		f.FetchFullText = arg.FetchFullText
+		f.UpdatedAt = time.Now()
+	})
+}
+
+func (db *DB) SetFeedImage(ctx context.Context, arg database.SetFeedImageParams) error {
+	return db.updateFeed(arg.ID, func(f *database.Feed) {
+		f.ImageUrl = arg.ImageUrl
+		f.UpdatedAt = time.Now()
+	})
+}
+
+func (db *DB) SetFeedKind(ctx context.Context, arg database.SetFeedKindParams) error {
+	return db.updateFeed(arg.ID, func(f *database.Feed) {
+		f.Kind = arg.Kind
+		f.UpdatedAt = time.Now()
+	})
+}
+
+func (db *DB) SetFeedMetadata(ctx context.Context, arg database.SetFeedMetadataParams) error {
+	return db.updateFeed(arg.ID, func(f *database.Feed) {
+		f.Title = arg.Title
+		f.Description = arg.Description
+		f.SiteUrl = arg.SiteUrl
+		f.Language = arg.Language
+	})
+}
+
+func (db *DB) SetFeedSelectors(ctx context.Context, arg database.SetFeedSelectorsParams) error {
+	return db.updateFeed(arg.ID, func(f *database.Feed) {
+		f.Kind = "html"
+		f.ItemSelector = arg.ItemSelector
+		f.TitleSelector = arg.TitleSelector
+		f.LinkSelector = arg.LinkSelector
+		f.DateSelector = arg.DateSelector
+		f.SummarySelector = arg.SummarySelector
+		f.UpdatedAt = time.Now()
+	})
+}
+
+// Follows
+
+func (db *DB) CreateFeedFollow(ctx context.Context, arg database.CreateFeedFollowParams) (database.CreateFeedFollowRow, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for _, f := range db.follows {
+		if f.ID == arg.ID {
+			return database.CreateFeedFollowRow{}, duplicate("feed_follows.id")
+		}
+		if f.UserID == arg.UserID && f.FeedID == arg.FeedID {
+			return database.CreateFeedFollowRow{}, duplicate("feed_follows.user_id, feed_follows.feed_id")
+		}
+	}
+
+	row := database.CreateFeedFollowRow{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		UserID:    arg.UserID,
+		FeedID:    arg.FeedID,
+	}
+	// the foreign keys: both have to exist
+	userFound, feedFound := false, false
+	for _, u := range db.users {
+		if arg.UserID.Valid && u.ID == arg.UserID.UUID {
+			row.UserName, userFound = u.Name, true
+		}
+	}
+	for _, f := range db.feeds {
+		if arg.FeedID.Valid && f.ID == arg.FeedID.UUID {
+			row.FeedName, feedFound = f.Name, true
+		}
+	}
+	if (arg.UserID.Valid && !userFound) || (arg.FeedID.Valid && !feedFound) {
+		return database.CreateFeedFollowRow{}, fmt.Errorf("FOREIGN KEY constraint failed")
+	}
+
+	db.follows = append(db.follows, database.FeedFollow{
+		ID: arg.ID, CreatedAt: arg.CreatedAt, UpdatedAt: arg.UpdatedAt, UserID: arg.UserID, FeedID: arg.FeedID,
+	})
+	return row, nil
+}
+
+func (db *DB) Delete_Feed_Follow(ctx context.Context, arg database.Delete_Feed_FollowParams) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var follows []database.FeedFollow
+	for _, f := range db.follows {
+		if f.UserID == arg.UserID && f.FeedID == arg.FeedID {
+			continue
+		}
+		follows = append(follows, f)
+	}
+	db.follows = follows
+	return nil
+}
+
+func (db *DB) GetFeedFollowsForUser(ctx context.Context, arg database.GetFeedFollowsForUserParams) ([]database.GetFeedFollowsForUserRow, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var rows []database.GetFeedFollowsForUserRow
+	for _, follow := range db.follows {
+		user, ok := db.user(follow.UserID)
+		if !ok || user.Name != arg.Name || !inFolder(follow, arg.Folder) {
+			continue
+		}
+		feed, ok := db.feed(follow.FeedID)
+		if !ok {
+			continue
+		}
+		unread := 0
+		for _, p := range db.posts {
+			if p.FeedID.Valid && p.FeedID.UUID == feed.ID && !db.isRead(user.ID, p.ID) {
+				unread++
+			}
+		}
+		rows = append(rows, database.GetFeedFollowsForUserRow{
+			Name: feed.Name, Url: feed.Url, Title: feed.Title, SiteUrl: feed.SiteUrl, Description: feed.Description,
+			Folder: follow.Folder, Unread: int64(unread),
+		})
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Folder.String != rows[j].Folder.String {
+			return rows[i].Folder.String < rows[j].Folder.String
+		}
+		return rows[i].Name < rows[j].Name
+	})
+	return rows, nil
+}
+
+func (db *DB) SetFollowFolder(ctx context.Context, arg database.SetFollowFolderParams) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var n int64
+	for i, f := range db.follows {
+		if f.UserID == arg.UserID && f.FeedID == arg.FeedID {
+			db.follows[i].Folder = arg.Folder
+			db.follows[i].UpdatedAt = time.Now()
+			n++
+		}
+	}
+	return n, nil
+}
+
+// inFolder is the folder filter of the queries, folder names match without case
+func inFolder(follow database.FeedFollow, folder sql.NullString) bool {
+	return !folder.Valid || (follow.Folder.Valid && strings.EqualFold(follow.Folder.String, folder.String))
+}
+
+func (db *DB) user(id uuid.NullUUID) (database.User, bool) {
+	for _, u := range db.users {
+		if id.Valid && u.ID == id.UUID {
+			return u, true
+		}
+	}
+	return database.User{}, false
+}
+
+func (db *DB) feed(id uuid.NullUUID) (database.Feed, bool) {
+	for _, f := range db.feeds {
+		if id.Valid && f.ID == id.UUID {
+			return f, true
+		}
+	}
+	return database.Feed{}, false
+}
+
+// followed returns the follows of userID, keyed by feed
+func (db *DB) followed(userID uuid.NullUUID) map[uuid.UUID]database.FeedFollow {
+	follows := map[uuid.UUID]database.FeedFollow{}
+	for _, f := range db.follows {
+		if userID.Valid && f.UserID == userID && f.FeedID.Valid {
+			follows[f.FeedID.UUID] = f
+		}
+	}
+	return follows
+}
+
+// Posts
+
+func (db *DB) CreatePost(ctx context.Context, arg database.CreatePostParams) (database.Post, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for _, p := range db.posts {
+		switch {
+		case p.ID == arg.ID:
+			return database.Post{}, duplicate("posts.id")
+		case p.Title == arg.Title:
+			return database.Post{}, duplicate("posts.title")
+		case p.Url == arg.Url:
+			return database.Post{}, duplicate("posts.url")
+		}
+	}
+	post := database.Post{
+		ID:          arg.ID,
+		CreatedAt:   arg.CreatedAt,
+		UpdatedAt:   arg.UpdatedAt,
+		Title:       arg.Title,
+		Url:         arg.Url,
+		Description: arg.Description,
+		PublishedAt: arg.PublishedAt,
+		FeedID:      arg.FeedID,
+	}
+	db.posts = append(db.posts, post)
+	return post, nil
+}
+
+// CreatePosts is the multi-row insert with ON CONFLICT DO NOTHING: duplicates are skipped, not errors
+func (db *DB) CreatePosts(ctx context.Context, arg []database.CreatePostParams) ([]database.Post, error) {
+	var created []database.Post
+	for _, p := range arg {
+		post, err := db.CreatePost(ctx, p)
+		if err != nil && strings.HasPrefix(err.Error(), "UNIQUE constraint failed") {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, post)
+	}
+	return created, nil
+}
+
+func (db *DB) GetPostByURL(ctx context.Context, url string) (database.Post, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for _, p := range db.posts {
+		if p.Url == url {
+			return p, nil
+		}
+	}
+	return database.Post{}, sql.ErrNoRows
+}
+
+// newestFirst is ORDER BY published_at DESC, where Postgres puts the posts without a date first
+func newestFirst(posts []database.Post) {
+	sort.SliceStable(posts, func(i, j int) bool {
+		a, b := posts[i].PublishedAt, posts[j].PublishedAt
+		if !a.Valid || !b.Valid {
+			return !a.Valid && b.Valid
+		}
+		return a.Time.After(b.Time)
+	})
+}
+
+func limit[T any](rows []T, n int32) []T {
+	if n >= 0 && int(n) < len(rows) {
+		return rows[:n]
+	}
+	return rows
+}
+
+func (db *DB) GetPostsForUser(ctx context.Context, arg database.GetPostsForUserParams) ([]database.GetPostsForUserRow, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	follows := db.followed(arg.UserID)
+	var posts []database.Post
+	for _, p := range db.posts {
+		if follow, ok := follows[p.FeedID.UUID]; ok && p.FeedID.Valid {
+			if arg.UnreadOnly && db.isRead(arg.UserID.UUID, p.ID) || !inFolder(follow, arg.Folder) {
+				continue
+			}
+			posts = append(posts, p)
+		}
+	}
+	newestFirst(posts)
+
+	var rows []database.GetPostsForUserRow
+	for _, p := range limit(posts, arg.Limit) {
+		a := follows[p.FeedID.UUID]
+		b, _ := db.feed(p.FeedID)
+		rows = append(rows, database.GetPostsForUserRow{
+			ID:          p.ID,
+			Title:       p.Title,
+			Url:         p.Url,
+			Description: p.Description,
+			Content:     p.Content,
+			PublishedAt: p.PublishedAt,
+			ArchivePath: p.ArchivePath,
+			FeedName:    b.Name,
+			Folder:      a.Folder,
+			IsRead:      db.isRead(arg.UserID.UUID, p.ID),
+		})
+	}
+	return rows, nil
+}
+
+func (db *DB) GetPostsToArchive(ctx context.Context, arg database.GetPostsToArchiveParams) ([]database.Post, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	follows := db.followed(arg.UserID)
+	var posts []database.Post
+	for _, p := range db.posts {
+		if _, ok := follows[p.FeedID.UUID]; ok && p.FeedID.Valid && !p.ArchivePath.Valid {
+			posts = append(posts, p)
+		}
+	}
+	newestFirst(posts)
+	return limit(posts, arg.Limit), nil
+}
+
+func (db *DB) updatePost(id uuid.UUID, change func(p *database.Post)) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for i := range db.posts {
+		if db.posts[i].ID == id {
+			change(&db.posts[i])
+		}
+	}
+	return nil
+}
+
+func (db *DB) SetPostArchive(ctx context.Context, arg database.SetPostArchiveParams) error {
+	now := time.Now()
+	return db.updatePost(arg.ID, func(p *database.Post) {
+		p.ArchivePath = arg.ArchivePath
+		p.ArchivedAt = sql.NullTime{Time: now, Valid: true}
+		p.UpdatedAt = now
+	})
+}
+
+func (db *DB) SetPostContent(ctx context.Context, arg database.SetPostContentParams) error {
+	return db.updatePost(arg.ID, func(p *database.Post) {
+		p.Content = arg.Content
+		p.UpdatedAt = time.Now()
+	})
+}
+
+// Reads
+
+func (db *DB) isRead(userID, postID uuid.UUID) bool {
+	for _, r := range db.reads {
+		if r.UserID == userID && r.PostID == postID {
+			return true
+		}
+	}
+	return false
+}
+
+// markRead marks the posts matching as read by userID, ON CONFLICT DO NOTHING, and returns how many weren't yet
+func (db *DB) markRead(userID uuid.UUID, match func(p database.Post) bool) int64 {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	n := int64(0)
+	for _, p := range db.posts {
+		if match(p) && !db.isRead(userID, p.ID) {
+			db.reads = append(db.reads, database.PostRead{UserID: userID, PostID: p.ID, ReadAt: time.Now()})
+			n++
+		}
+	}
+	return n
+}
+
+// markUnread deletes the reads of userID of the posts matching, and returns how many there were
+func (db *DB) markUnread(userID uuid.UUID, match func(p database.Post) bool) int64 {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	matching := map[uuid.UUID]bool{}
+	for _, p := range db.posts {
+		if match(p) {
+			matching[p.ID] = true
+		}
+	}
+	n := int64(0)
+	var reads []database.PostRead
+	for _, r := range db.reads {
+		if r.UserID == userID && matching[r.PostID] {
+			n++
+			continue
+		}
+		reads = append(reads, r)
+	}
+	db.reads = reads
+	return n
+}
+
+// olderThan is coalesce(published_at, created_at) < before
+func olderThan(p database.Post, before sql.NullTime) bool {
+	date := p.CreatedAt
+	if p.PublishedAt.Valid {
+		date = p.PublishedAt.Time
+	}
+	return before.Valid && date.Before(before.Time)
+}
+
+func (db *DB) MarkPostRead(ctx context.Context, arg database.MarkPostReadParams) error {
+	db.markRead(arg.UserID, func(p database.Post) bool { return p.ID == arg.PostID })
+	return nil
+}
+
+func (db *DB) MarkPostUnread(ctx context.Context, arg database.MarkPostUnreadParams) error {
+	db.markUnread(arg.UserID, func(p database.Post) bool { return p.ID == arg.PostID })
+	return nil
+}
+
+func (db *DB) MarkFeedRead(ctx context.Context, arg database.MarkFeedReadParams) (int64, error) {
+	return db.markRead(arg.UserID, func(p database.Post) bool { return arg.FeedID.Valid && p.FeedID == arg.FeedID }), nil
+}
+
+func (db *DB) MarkFeedUnread(ctx context.Context, arg database.MarkFeedUnreadParams) (int64, error) {
+	return db.markUnread(arg.UserID, func(p database.Post) bool { return arg.FeedID.Valid && p.FeedID == arg.FeedID }), nil
+}
+
+// MarkReadBefore only marks posts of the feeds the user follows
+func (db *DB) MarkReadBefore(ctx context.Context, arg database.MarkReadBeforeParams) (int64, error) {
+	db.mu.Lock()
+	follows := db.followed(arg.UserID)
+	db.mu.Unlock()
+
+	return db.markRead(arg.UserID.UUID, func(p database.Post) bool {
+		_, ok := follows[p.FeedID.UUID]
+		return ok && p.FeedID.Valid && olderThan(p, arg.Before)
+	}), nil
+}
+
+func (db *DB) MarkUnreadBefore(ctx context.Context, arg database.MarkUnreadBeforeParams) (int64, error) {
+	return db.markUnread(arg.UserID, func(p database.Post) bool { return olderThan(p, arg.Before) }), nil
+}
+
+// Bookmarks
+
+func (db *DB) UpsertBookmark(ctx context.Context, arg database.UpsertBookmarkParams) (database.Bookmark, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for i, b := range db.saved {
+		if b.UserID == arg.UserID && b.Url == arg.Url {
+			b.UpdatedAt = arg.UpdatedAt
+			b.PostID = arg.PostID
+			b.Title = arg.Title
+			b.Description = arg.Description
+			b.PublishedAt = arg.PublishedAt
+			b.FeedName = arg.FeedName
+			if arg.Note.Valid {
+				b.Note = arg.Note
+			}
+			db.saved[i] = b
+			return b, nil
+		}
+	}
+	if _, ok := db.user(uuid.NullUUID{UUID: arg.UserID, Valid: true}); !ok {
+		return database.Bookmark{}, fmt.Errorf("FOREIGN KEY constraint failed")
+	}
+	b := database.Bookmark{
+		ID:          arg.ID,
+		CreatedAt:   arg.CreatedAt,
+		UpdatedAt:   arg.UpdatedAt,
+		UserID:      arg.UserID,
+		PostID:      arg.PostID,
+		Url:         arg.Url,
+		Title:       arg.Title,
+		Description: arg.Description,
+		PublishedAt: arg.PublishedAt,
+		FeedName:    arg.FeedName,
+		Note:        arg.Note,
+	}
+	db.saved = append(db.saved, b)
+	return b, nil
+}
+
+func (db *DB) AddBookmarkTag(ctx context.Context, arg database.AddBookmarkTagParams) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for _, t := range db.tags {
+		if t == database.BookmarkTag(arg) {
+			return nil
+		}
+	}
+	db.tags = append(db.tags, database.BookmarkTag(arg))
+	return nil
+}
+
+func (db *DB) DeleteBookmark(ctx context.Context, arg database.DeleteBookmarkParams) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	n := int64(0)
+	deleted := map[uuid.UUID]bool{}
+	var saved []database.Bookmark
+	for _, b := range db.saved {
+		if b.UserID == arg.UserID && b.Url == arg.Url {
+			deleted[b.ID] = true
+			n++
+			continue
+		}
+		saved = append(saved, b)
+	}
+	db.saved = saved
+
+	var tags []database.BookmarkTag
+	for _, t := range db.tags {
+		if !deleted[t.BookmarkID] {
+			tags = append(tags, t)
+		}
+	}
+	db.tags = tags
+	return n, nil
+}
+
+func (db *DB) GetBookmarks(ctx context.Context, arg database.GetBookmarksParams) ([]database.Bookmark, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var saved []database.Bookmark
+	for _, b := range db.saved {
+		if b.UserID != arg.UserID {
+			continue
+		}
+		if arg.Tag.Valid {
+			tagged := false
+			for _, t := range db.tags {
+				tagged = tagged || (t.BookmarkID == b.ID && t.Tag == arg.Tag.String)
+			}
+			if !tagged {
+				continue
+			}
+		}
+		saved = append(saved, b)
+	}
+	sort.SliceStable(saved, func(i, j int) bool { return saved[i].CreatedAt.After(saved[j].CreatedAt) })
+	return saved, nil
+}
+
+func (db *DB) GetBookmarkTags(ctx context.Context, userID uuid.UUID) ([]database.BookmarkTag, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	mine := map[uuid.UUID]bool{}
+	for _, b := range db.saved {
+		if b.UserID == userID {
+			mine[b.ID] = true
+		}
+	}
+	var tags []database.BookmarkTag
+	for _, t := range db.tags {
+		if mine[t.BookmarkID] {
+			tags = append(tags, t)
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].Tag < tags[j].Tag })
+	return tags, nil
+}
+
+/* SearchPostsPostgres understands the tsquery strings internal/search writes, roughly: words match anywhere
+in the title or the text (so every word acts as a prefix), phrases as their words in any order. The
+rank is 10 for each word in the title and 1 for each in the text, like the A and B weights. */
+
+func (db *DB) SearchPostsPostgres(ctx context.Context, arg database.SearchPostsPostgresParams) ([]database.SearchPostsPostgresRow, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var rows []database.SearchPostsRow
+	for _, p := range db.posts {
+		feed, ok := db.feed(p.FeedID)
+		if !ok {
+			continue
+		}
+		if arg.FeedName.Valid && feed.Name != arg.FeedName.String {
+			continue
+		}
+		if arg.Since.Valid && (!p.PublishedAt.Valid || p.PublishedAt.Time.Before(arg.Since.Time)) {
+			continue
+		}
+		if arg.Until.Valid && (!p.PublishedAt.Valid || !p.PublishedAt.Time.Before(arg.Until.Time)) {
+			continue
+		}
+		if _, ok := db.followed(arg.UserID)[feed.ID]; arg.FollowedOnly && !ok {
+			continue
+		}
+
+		title := strings.ToLower(p.Title)
+		text := strings.ToLower(p.Description + " " + p.Content.String)
+		words, ok := matchTSQuery(arg.Query, title+" "+text)
+		if !ok {
+			continue
+		}
+		rank := 0
+		for _, w := range words {
+			rank += 10*strings.Count(title, w) + strings.Count(text, w)
+		}
+		snippet := p.Description
+		for _, w := range words {
+			snippet = strings.ReplaceAll(snippet, w, "«"+w+"»")
+		}
+		rows = append(rows, database.SearchPostsRow{
+			ID:          p.ID,
+			Title:       p.Title,
+			Url:         p.Url,
+			PublishedAt: p.PublishedAt,
+			FeedName:    feed.Name,
+			Rank:        float32(rank),
+			Snippet:     snippet,
+		})
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Rank > rows[j].Rank })
+	return limit(rows, arg.MaxResults), nil
+}
+
+// matchTSQuery tells whether text matches query (a | b & !c), and which words it matched on
+func matchTSQuery(query, text string) ([]string, bool) {
+	for _, group := range strings.Split(query, "|") {
+		var words []string
+		matched := true
+		for _, term := range strings.Split(group, "&") {
+			term = strings.TrimSpace(term)
+			exclude := strings.HasPrefix(term, "!")
+			term = strings.Trim(term, "!() ")
+			found := true
+			var termWords []string
+			for _, w := range strings.Split(term, "<->") {
+				w = strings.TrimSuffix(strings.TrimSpace(w), ":*")
+				termWords = append(termWords, w)
+				found = found && strings.Contains(text, w)
+			}
+			if found == exclude {
+				matched = false
+				break
+			}
+			if !exclude {
+				words = append(words, termWords...)
+			}
+		}
+		if matched {
+			return words, true
+		}
+	}
+	return nil, false
+}
+
+// SearchPosts searches like Postgres does, with the query as a tsquery
+
+func (db *DB) SearchPosts(ctx context.Context, query search.Query, arg database.SearchPostsParams) ([]database.SearchPostsRow, error) {
+	arg.Query = query.TSQuery()
+	return db.SearchPostsPostgres(ctx, arg)
+}
+
+// WebSub subscriptions
+
+func (db *DB) UpsertWebSubSubscription(ctx context.Context, arg database.UpsertWebSubSubscriptionParams) (database.WebsubSubscription, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for i, s := range db.subs {
+		if s.FeedID == arg.FeedID {
+			s.UpdatedAt = arg.UpdatedAt
+			s.HubUrl = arg.HubUrl
+			s.TopicUrl = arg.TopicUrl
+			s.Secret = arg.Secret
+			db.subs[i] = s
+			return s, nil
+		}
+	}
+	if _, ok := db.feed(uuid.NullUUID{UUID: arg.FeedID, Valid: true}); !ok {
+		return database.WebsubSubscription{}, fmt.Errorf("FOREIGN KEY constraint failed")
+	}
+	sub := database.WebsubSubscription{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		FeedID:    arg.FeedID,
+		HubUrl:    arg.HubUrl,
+		TopicUrl:  arg.TopicUrl,
+		Secret:    arg.Secret,
+	}
+	db.subs = append(db.subs, sub)
+	return sub, nil
+}
+
+func (db *DB) GetWebSubSubscription(ctx context.Context, feedID uuid.UUID) (database.WebsubSubscription, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for _, s := range db.subs {
+		if s.FeedID == feedID {
+			return s, nil
+		}
+	}
+	return database.WebsubSubscription{}, sql.ErrNoRows
+}
+
+func (db *DB) SetWebSubLease(ctx context.Context, arg database.SetWebSubLeaseParams) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for i := range db.subs {
+		if db.subs[i].FeedID == arg.FeedID {
+			db.subs[i].LeaseExpiresAt = arg.LeaseExpiresAt
+			db.subs[i].UpdatedAt = time.Now()
+		}
+	}
+	return nil
+}
+
+func (db *DB) DeleteWebSubSubscription(ctx context.Context, feedID uuid.UUID) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var subs []database.WebsubSubscription
+	for _, s := range db.subs {
+		if s.FeedID != feedID {
+			subs = append(subs, s)
+		}
+	}
+	db.subs = subs
+	return nil
+}
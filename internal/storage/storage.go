@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+	"modernc.org/sqlite"
+)
+
+/* gator runs on PostgreSQL, or for a single user on an SQLite file with no server at all. The scheme
+of the database URL picks which: postgres://... or sqlite:///path/to/gator.db. Both run the very same
+sqlc queries, the few Postgres things they use are made to work on SQLite here (see init). */
+
+type Dialect string
+
+const (
+	Postgres Dialect = "postgres"
+	SQLite   Dialect = "sqlite"
+)
+
+// DB is an open database and which kind it is, the queries run on it as on a plain *sql.DB
+type DB struct {
+	*sql.DB
+	Dialect Dialect
+}
+
+// sqliteTimeFormat is how the driver writes times (with _time_format=sqlite) and how it reads them back
+const sqliteTimeFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+func init() {
+	// the queries use Postgres' NOW(), SQLite only has CURRENT_TIMESTAMP (in UTC, without fractions)
+	sqlite.MustRegisterScalarFunction("now", 0, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		return time.Now().Format(sqliteTimeFormat), nil
+	})
+}
+
+// Open connects to dbURL and pings it, so a wrong url or a stopped server shows up right away
+
+func Open(ctx context.Context, dbURL string) (*DB, error) {
+	dialect, driverName, dsn, err := parse(dbURL)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error opening the database: %w", err)
+	}
+	if dialect == SQLite {
+		// one writer at a time is all SQLite does, queue them here instead of failing with "database is locked"
+		db.SetMaxOpenConns(1)
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error connecting to the database: %w", err)
+	}
+	return &DB{DB: db, Dialect: dialect}, nil
+}
+
+// parse picks the driver for a database url, and turns sqlite urls into what the sqlite driver expects
+func parse(dbURL string) (dialect Dialect, driverName, dsn string, err error) {
+	switch {
+	case strings.HasPrefix(dbURL, "postgres://"), strings.HasPrefix(dbURL, "postgresql://"):
+		return Postgres, "postgres", dbURL, nil
+
+	case strings.HasPrefix(dbURL, "sqlite:"):
+		path := strings.TrimPrefix(strings.TrimPrefix(dbURL, "sqlite:"), "//")
+		if path == "" {
+			return "", "", "", fmt.Errorf("no file in the sqlite url %q, use sqlite:///path/to/gator.db", dbURL)
+		}
+		if strings.HasPrefix(path, "~/") {
+			homeDir, err := os.UserHomeDir()
+			if err != nil {
+				return "", "", "", err
+			}
+			path = filepath.Join(homeDir, path[2:])
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return "", "", "", fmt.Errorf("error creating the database directory: %w", err)
+		}
+		pragmas := "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite"
+		return SQLite, "sqlite", "file:" + path + pragmas, nil
+	}
+
+	scheme, _, _ := strings.Cut(dbURL, ":")
+	return "", "", "", fmt.Errorf("unsupported database %q, the url has to start with postgres:// or sqlite://", scheme)
+}
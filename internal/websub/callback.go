@@ -0,0 +1,118 @@
+package websub
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Subscription is what the callback needs to know about a subscription it receives pushes for
+type Subscription struct {
+	Topic  string
+	Secret string
+}
+
+/* Callback is the HTTP side of WebSub, the URL hubs talk to. Every subscription gets its own path,
+/websub/{id}, so we know which feed a push belongs to without trusting anything in the body.
+Storage is left to the caller through the three funcs, this package doesn't know about the database. */
+
+type Callback struct {
+	// Lookup finds the subscription behind an id, ok is false if there isn't one (anymore)
+	Lookup func(ctx context.Context, id string) (sub Subscription, ok bool)
+	// Verified is called when the hub confirms a subscribe (with the lease it granted), or reports it denied it.
+	// An error answers the hub with a 500, so it doesn't count on a subscription we couldn't record.
+	Verified func(ctx context.Context, id, mode string, lease time.Duration) error
+	// Deliver gets the body of every push that carries a valid signature
+	Deliver func(ctx context.Context, id string, body []byte) error
+}
+
+// CallbackPath is where the callback lives, the full callback URL for a subscription is the public base + CallbackPath + id
+const CallbackPath = "/websub/"
+
+func (c *Callback) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+CallbackPath+"{id}", c.verify)
+	mux.HandleFunc("POST "+CallbackPath+"{id}", c.deliver)
+	return mux
+}
+
+// verify answers the hub's intent verification: echo hub.challenge if we really asked for this, 404 if not
+func (c *Callback) verify(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	query := r.URL.Query()
+	mode := query.Get("hub.mode")
+	topic := query.Get("hub.topic")
+
+	sub, ok := c.Lookup(r.Context(), id)
+
+	switch mode {
+	case "subscribe":
+		if !ok || sub.Topic != topic {
+			http.NotFound(w, r)
+			return
+		}
+		lease, _ := strconv.Atoi(query.Get("hub.lease_seconds"))
+		if err := c.Verified(r.Context(), id, mode, time.Duration(lease)*time.Second); err != nil {
+			http.Error(w, "couldn't record the subscription", http.StatusInternalServerError)
+			return
+		}
+
+	case "unsubscribe":
+		// we only ever unsubscribe by deleting the subscription first, if it's still there someone else is asking
+		if ok {
+			http.NotFound(w, r)
+			return
+		}
+
+	case "denied":
+		if ok && sub.Topic == topic {
+			if err := c.Verified(r.Context(), id, mode, 0); err != nil {
+				http.Error(w, "couldn't record the denial", http.StatusInternalServerError)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+
+	default:
+		http.Error(w, "unknown hub.mode", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	fmt.Fprint(w, query.Get("hub.challenge"))
+}
+
+/* deliver takes a push. A push with a bad signature still gets a 2xx (that's what the spec asks, so a
+forger can't tell it failed) but is thrown away. A failed ingest gets a 500 so the hub tries again. */
+
+func (c *Callback) deliver(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	sub, ok := c.Lookup(r.Context(), id)
+	if !ok {
+		// 410 tells the hub to drop the subscription
+		http.Error(w, "no such subscription", http.StatusGone)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPushSize+1))
+	if err != nil || len(body) > maxPushSize {
+		http.Error(w, "push too large or unreadable", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if !VerifySignature(sub.Secret, r.Header.Get("X-Hub-Signature"), body) {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	if err := c.Deliver(r.Context(), id, body); err != nil {
+		http.Error(w, "couldn't ingest push", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
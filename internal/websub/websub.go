@@ -0,0 +1,137 @@
+package websub
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	rss "github.com/azhagan2/blog_aggregator/internal/RSS"
+)
+
+/* WebSub (formerly PubSubHubbub) lets a feed push new content to us instead of us polling it. The feed
+names a hub with <atom:link rel="hub">, we ask the hub to subscribe a callback URL to the feed's topic,
+the hub checks we really asked by GETting the callback with a challenge, and from then on POSTs every
+update to the callback, signed with a secret we gave it. Subscriptions expire after a lease and have to
+be renewed. https://www.w3.org/TR/websub/ */
+
+const (
+	// Lease is how long we ask the hub to keep a subscription, hubs are free to pick something else
+	Lease = 7 * 24 * time.Hour
+	// RenewBefore is how close to the end of a lease we subscribe again
+	RenewBefore = 24 * time.Hour
+	// maxPushSize caps what a hub can send us in one push, the same as a normal fetch
+	maxPushSize = 10 << 20
+)
+
+// NewSecret makes the random secret the hub signs pushes with
+
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating websub secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Subscribe asks hub to push topic to callback. The hub answers 202 and verifies with a GET to callback later.
+
+func Subscribe(ctx context.Context, hub, topic, callback, secret string) error {
+	return request(ctx, "subscribe", hub, topic, callback, secret)
+}
+
+// Unsubscribe asks hub to stop pushing topic to callback
+
+func Unsubscribe(ctx context.Context, hub, topic, callback string) error {
+	return request(ctx, "unsubscribe", hub, topic, callback, "")
+}
+
+func request(ctx context.Context, mode, hub, topic, callback, secret string) error {
+	form := url.Values{
+		"hub.mode":     {mode},
+		"hub.topic":    {topic},
+		"hub.callback": {callback},
+	}
+	if mode == "subscribe" {
+		form.Set("hub.lease_seconds", strconv.Itoa(int(Lease.Seconds())))
+		if secret != "" {
+			form.Set("hub.secret", secret)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hub, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("error in the request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", "gator")
+
+	// through the proxy and CA bundles of the config, like every fetch
+	client, err := rss.Client(rss.FetchOptions{})
+	if err != nil {
+		return err
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("hub refused %s: %s %s", mode, res.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+/* VerifySignature checks the X-Hub-Signature header ("sha256=hex...") of a push against the secret.
+The spec allows sha1, sha256, sha384 and sha512, anything else (or a missing header) fails. */
+
+func VerifySignature(secret, header string, body []byte) bool {
+	method, signature, ok := strings.Cut(header, "=")
+	if !ok {
+		return false
+	}
+
+	var h func() hash.Hash
+	switch strings.ToLower(method) {
+	case "sha1":
+		h = sha1.New
+	case "sha256":
+		h = sha256.New
+	case "sha384":
+		h = sha512.New384
+	case "sha512":
+		h = sha512.New
+	default:
+		return false
+	}
+
+	want, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(h, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), want)
+}
+
+// Sign makes an X-Hub-Signature header for body, it's what a hub does (and what a fake hub in tests needs)
+
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,211 @@
+package websub
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	rss "github.com/azhagan2/blog_aggregator/internal/RSS"
+)
+
+// fakeHub verifies every subscribe request against the callback, like a real hub, and remembers the secret
+type fakeHub struct {
+	t        *testing.T
+	mu       sync.Mutex
+	secret   string
+	callback string
+	verified bool
+}
+
+func (h *fakeHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	verify, _ := url.Parse(r.Form.Get("hub.callback"))
+	query := verify.Query()
+	query.Set("hub.mode", r.Form.Get("hub.mode"))
+	query.Set("hub.topic", r.Form.Get("hub.topic"))
+	query.Set("hub.challenge", "challenge-123")
+	query.Set("hub.lease_seconds", "3600")
+	verify.RawQuery = query.Encode()
+
+	res, err := http.Get(verify.String())
+	if err != nil {
+		h.t.Errorf("verification request failed: %v", err)
+		return
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+
+	h.mu.Lock()
+	h.secret = r.Form.Get("hub.secret")
+	h.callback = r.Form.Get("hub.callback")
+	h.verified = res.StatusCode == http.StatusOK && string(body) == "challenge-123"
+	h.mu.Unlock()
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *fakeHub) push(body, signature string) (*http.Response, error) {
+	req, _ := http.NewRequest(http.MethodPost, h.callback, strings.NewReader(body))
+	req.Header.Set("X-Hub-Signature", signature)
+	return http.DefaultClient.Do(req)
+}
+
+func TestSubscribeVerifyAndPush(t *testing.T) {
+	const topic = "https://blog.example.com/feed.xml"
+	secret, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lease time.Duration
+	var delivered []string
+	callback := &Callback{
+		Lookup: func(ctx context.Context, id string) (Subscription, bool) {
+			return Subscription{Topic: topic, Secret: secret}, id == "42"
+		},
+		Verified: func(ctx context.Context, id, mode string, l time.Duration) error {
+			lease = l
+			return nil
+		},
+		Deliver: func(ctx context.Context, id string, body []byte) error {
+			delivered = append(delivered, string(body))
+			return nil
+		},
+	}
+	subscriber := httptest.NewServer(callback.Handler())
+	defer subscriber.Close()
+
+	hub := &fakeHub{t: t}
+	hubServer := httptest.NewServer(hub)
+	defer hubServer.Close()
+
+	if err := Subscribe(context.Background(), hubServer.URL, topic, subscriber.URL+CallbackPath+"42", secret); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if !hub.verified {
+		t.Fatal("callback didn't echo the challenge")
+	}
+	if hub.secret != secret {
+		t.Errorf("hub got secret %q, want %q", hub.secret, secret)
+	}
+	if lease != time.Hour {
+		t.Errorf("lease = %v, want 1h", lease)
+	}
+
+	content := "<rss><channel><item><title>pushed</title></item></channel></rss>"
+	res, err := hub.push(content, Sign(secret, []byte(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusAccepted {
+		t.Errorf("signed push status = %d, want 202", res.StatusCode)
+	}
+
+	res, err = hub.push("<rss>forged</rss>", Sign("wrong secret", []byte("<rss>forged</rss>")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusAccepted {
+		t.Errorf("forged push status = %d, want 202", res.StatusCode)
+	}
+
+	if len(delivered) != 1 || delivered[0] != content {
+		t.Errorf("delivered = %q, want only the signed push", delivered)
+	}
+}
+
+func TestVerifyRejectsWrongTopic(t *testing.T) {
+	callback := &Callback{
+		Lookup: func(ctx context.Context, id string) (Subscription, bool) {
+			return Subscription{Topic: "https://blog.example.com/feed.xml", Secret: "s"}, true
+		},
+		Verified: func(ctx context.Context, id, mode string, l time.Duration) error {
+			t.Error("Verified called for a topic we never subscribed to")
+			return nil
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, CallbackPath+"1?hub.mode=subscribe&hub.topic=https://evil.example.com&hub.challenge=x", nil)
+	rec := httptest.NewRecorder()
+	callback.Handler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", rec.Code)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte("hello")
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{"sha256", Sign("secret", body), true},
+		{"sha1", "sha1=9a45b0a0e4e1b0b3f8fdd6a6f0ef4b1c1d10a4f3", false},
+		{"wrong secret", Sign("other", body), false},
+		{"missing", "", false},
+		{"unknown method", "md5=abc", false},
+		{"not hex", "sha256=zz", false},
+	}
+	for _, tt := range tests {
+		if got := VerifySignature("secret", tt.header, body); got != tt.want {
+			t.Errorf("%s: VerifySignature = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyFailsWhenNotRecorded(t *testing.T) {
+	callback := &Callback{
+		Lookup: func(ctx context.Context, id string) (Subscription, bool) {
+			return Subscription{Topic: "https://blog.example.com/feed.xml", Secret: "s"}, true
+		},
+		Verified: func(ctx context.Context, id, mode string, l time.Duration) error {
+			return errors.New("database is locked")
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, CallbackPath+"1?hub.mode=subscribe&hub.topic=https://blog.example.com/feed.xml&hub.challenge=x", nil)
+	rec := httptest.NewRecorder()
+	callback.Handler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError || rec.Body.String() == "x" {
+		t.Errorf("status = %d, body %q, want a 500 without the challenge", rec.Code, rec.Body.String())
+	}
+}
+
+func TestSubscribeThroughProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// a proxy gets the whole url of where the request goes
+		proxied = r.Method + " " + r.URL.String()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer proxy.Close()
+
+	if err := rss.ConfigureNetwork(rss.Network{Proxy: proxy.URL}); err != nil {
+		t.Fatal(err)
+	}
+	defer rss.ConfigureNetwork(rss.Network{})
+
+	err := Subscribe(context.Background(), "http://hub.example.com/", "https://blog.example.com/feed.xml", "https://gator.example.com/websub/1", "s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proxied != "POST http://hub.example.com/" {
+		t.Errorf("the proxy got %q, want the hub request", proxied)
+	}
+}
@@ -0,0 +1,19 @@
+-- name: GetPostsToArchive :many
+SELECT posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.content, posts.archive_path, posts.archived_at, posts.id, posts.feed_id
+FROM posts
+JOIN feed_follows a ON posts.feed_id = a.feed_id
+WHERE a.user_id = $1 AND posts.archive_path IS NULL
+ORDER BY posts.published_at DESC
+LIMIT $2;
+
+-- name: SetPostArchive :exec
+UPDATE posts
+SET archive_path = $2,
+    archived_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetPostByURL :one
+SELECT created_at, updated_at, title, url, description, published_at, content, archive_path, archived_at, id, feed_id
+FROM posts
+WHERE url = $1;
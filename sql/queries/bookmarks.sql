@@ -0,0 +1,39 @@
+-- name: UpsertBookmark :one
+-- saving a post again refreshes the copy, and the note when a new one is given
+INSERT INTO bookmarks (id, created_at, updated_at, user_id, post_id, url, title, description, published_at, feed_name, note)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+ON CONFLICT (user_id, url) DO UPDATE
+SET updated_at = excluded.updated_at,
+    post_id = excluded.post_id,
+    title = excluded.title,
+    description = excluded.description,
+    published_at = excluded.published_at,
+    feed_name = excluded.feed_name,
+    note = coalesce(excluded.note, bookmarks.note)
+RETURNING *;
+
+-- name: AddBookmarkTag :exec
+INSERT INTO bookmark_tags (bookmark_id, tag)
+VALUES ($1, $2)
+ON CONFLICT (bookmark_id, tag) DO NOTHING;
+
+-- name: DeleteBookmark :execrows
+DELETE FROM bookmarks
+WHERE user_id = $1 AND url = $2;
+
+-- name: GetBookmarks :many
+-- newest saved first, only the ones with the tag when one is given
+SELECT *
+FROM bookmarks
+WHERE user_id = sqlc.arg(user_id)
+    AND (CAST(sqlc.narg(tag) AS TEXT) IS NULL OR EXISTS (
+        SELECT 1 FROM bookmark_tags t WHERE t.bookmark_id = bookmarks.id AND t.tag = sqlc.narg(tag)
+    ))
+ORDER BY created_at DESC;
+
+-- name: GetBookmarkTags :many
+SELECT t.bookmark_id, t.tag
+FROM bookmark_tags t
+JOIN bookmarks ON bookmarks.id = t.bookmark_id
+WHERE bookmarks.user_id = $1
+ORDER BY t.tag;
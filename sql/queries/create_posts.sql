@@ -10,4 +10,4 @@ VALUES (
     $7,
     $8
 )
-RETURNING *;
\ No newline at end of file
+RETURNING created_at, updated_at, title, url, description, published_at, content, archive_path, archived_at, id, feed_id;
\ No newline at end of file
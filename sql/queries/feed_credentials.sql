@@ -0,0 +1,5 @@
+-- name: SetFeedCredentials :exec
+UPDATE feeds
+SET credentials = $2,
+    updated_at = NOW()
+WHERE id = $1;
@@ -0,0 +1,5 @@
+-- name: SetFeedImage :exec
+UPDATE feeds SET image_url = $2, updated_at = NOW() WHERE id = $1;
+
+-- name: SetFeedFavicon :exec
+UPDATE feeds SET favicon_path = $2, updated_at = NOW() WHERE id = $1;
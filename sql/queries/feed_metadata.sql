@@ -0,0 +1,7 @@
+-- name: SetFeedMetadata :exec
+UPDATE feeds
+SET title = $2,
+    description = $3,
+    site_url = $4,
+    language = $5
+WHERE id = $1;
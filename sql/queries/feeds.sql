@@ -8,4 +8,15 @@ VALUES (
     $5,
     $6
 )
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: GetFeedByName :one
+SELECT *
+FROM feeds
+WHERE name = $1;
+
+-- name: SetFeedKind :exec
+UPDATE feeds
+SET kind = $2,
+    updated_at = NOW()
+WHERE id = $1;
@@ -0,0 +1,11 @@
+-- name: SetFeedFullText :exec
+UPDATE feeds
+SET fetch_full_text = $2,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: SetPostContent :exec
+UPDATE posts
+SET content = $2,
+    updated_at = NOW()
+WHERE id = $1;
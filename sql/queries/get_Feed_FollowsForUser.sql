@@ -1,6 +1,19 @@
 -- name: GetFeedFollowsForUser :many
-SELECT feeds.name
+SELECT feeds.name, feeds.url, feeds.title, feeds.site_url, feeds.description, a.folder, (
+    SELECT count(*) FROM posts
+    WHERE posts.feed_id = feeds.id AND NOT EXISTS (
+        SELECT 1 FROM post_reads r WHERE r.post_id = posts.id AND r.user_id = users.id
+    )
+) AS unread
 FROM feed_follows a 
 INNER JOIN users on users.id = a.user_id
 INNER JOIN feeds on feeds.id = a.feed_id
-WHERE users.name = $1;
\ No newline at end of file
+WHERE users.name = sqlc.arg(name)
+    AND (CAST(sqlc.narg(folder) AS TEXT) IS NULL OR lower(a.folder) = lower(sqlc.narg(folder)))
+ORDER BY coalesce(a.folder, ''), feeds.name;
+
+-- name: SetFollowFolder :execrows
+UPDATE feed_follows
+SET folder = sqlc.narg(folder),
+    updated_at = now()
+WHERE user_id = sqlc.arg(user_id) AND feed_id = sqlc.arg(feed_id);
@@ -0,0 +1,4 @@
+-- name: GetFeed_ByURL :one
+SELECT *
+FROM feeds 
+WHERE url = $1;
\ No newline at end of file
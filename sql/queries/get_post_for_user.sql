@@ -1,8 +1,15 @@
 -- name: GetPostsForUser :many
-SELECT *
+SELECT posts.id, posts.title, posts.url, posts.description, posts.content, posts.published_at, posts.archive_path,
+    b.name AS feed_name, a.folder, EXISTS (
+    SELECT 1 FROM post_reads r WHERE r.post_id = posts.id AND r.user_id = a.user_id
+) AS is_read
 FROM posts 
 JOIN feed_follows a ON posts.feed_id = a.feed_id  
 JOIN feeds b ON a.feed_id = b.id
-WHERE a.user_id = $1
+WHERE a.user_id = sqlc.arg(user_id)
+    AND (NOT CAST(sqlc.arg(unread_only) AS BOOLEAN) OR NOT EXISTS (
+        SELECT 1 FROM post_reads r WHERE r.post_id = posts.id AND r.user_id = a.user_id
+    ))
+    AND (CAST(sqlc.narg(folder) AS TEXT) IS NULL OR lower(a.folder) = lower(sqlc.narg(folder)))
 ORDER BY posts.published_at DESC
-LIMIT $2;
\ No newline at end of file
+LIMIT sqlc.arg('limit');
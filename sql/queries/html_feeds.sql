@@ -0,0 +1,10 @@
+-- name: SetFeedSelectors :exec
+UPDATE feeds
+SET kind = 'html',
+    item_selector = $2,
+    title_selector = $3,
+    link_selector = $4,
+    date_selector = $5,
+    summary_selector = $6,
+    updated_at = NOW()
+WHERE id = $1;
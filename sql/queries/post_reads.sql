@@ -0,0 +1,34 @@
+-- name: MarkPostRead :exec
+INSERT INTO post_reads (user_id, post_id, read_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: MarkPostUnread :exec
+DELETE FROM post_reads
+WHERE user_id = $1 AND post_id = $2;
+
+-- name: MarkFeedRead :execrows
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT $1, posts.id, NOW()
+FROM posts
+WHERE posts.feed_id = $2
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: MarkFeedUnread :execrows
+DELETE FROM post_reads
+WHERE user_id = $1 AND post_id IN (SELECT posts.id FROM posts WHERE posts.feed_id = $2);
+
+-- name: MarkReadBefore :execrows
+-- the posts of every followed feed published before the date, or stored before it when they have no date
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT a.user_id, posts.id, NOW()
+FROM posts
+JOIN feed_follows a ON a.feed_id = posts.feed_id
+WHERE a.user_id = sqlc.arg(user_id) AND coalesce(posts.published_at, posts.created_at) < sqlc.arg(before)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: MarkUnreadBefore :execrows
+DELETE FROM post_reads
+WHERE user_id = sqlc.arg(user_id) AND post_id IN (
+    SELECT posts.id FROM posts WHERE coalesce(posts.published_at, posts.created_at) < sqlc.arg(before)
+);
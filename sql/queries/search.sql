@@ -0,0 +1,21 @@
+-- name: SearchPostsPostgres :many
+-- query is a to_tsquery string (see internal/search), the filters are skipped when NULL / false.
+-- post_search(...) is spelled out the way the index has it, so the index is used
+SELECT posts.id, posts.title, posts.url, posts.published_at, feeds.name AS feed_name,
+    ts_rank_cd(post_search(posts.title, posts.description, posts.content), to_tsquery('english', sqlc.arg(query))) AS rank,
+    ts_headline('english',
+        regexp_replace(coalesce(posts.content, posts.description), '<[^>]*>', ' ', 'g'),
+        to_tsquery('english', sqlc.arg(query)),
+        'StartSel=«, StopSel=», MaxFragments=2, MinWords=8, MaxWords=20, FragmentDelimiter=" … "')::text AS snippet
+FROM posts
+JOIN feeds ON feeds.id = posts.feed_id
+WHERE post_search(posts.title, posts.description, posts.content) @@ to_tsquery('english', sqlc.arg(query))
+    AND (sqlc.narg(feed_name)::text IS NULL OR feeds.name = sqlc.narg(feed_name))
+    AND (sqlc.narg(since)::timestamp IS NULL OR posts.published_at >= sqlc.narg(since))
+    AND (sqlc.narg(until)::timestamp IS NULL OR posts.published_at < sqlc.narg(until))
+    AND (NOT sqlc.arg(followed_only)::boolean OR EXISTS (
+        SELECT 1 FROM feed_follows
+        WHERE feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = sqlc.arg(user_id)
+    ))
+ORDER BY rank DESC, posts.published_at DESC NULLS LAST
+LIMIT sqlc.arg(max_results);
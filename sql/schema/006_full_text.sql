@@ -0,0 +1,7 @@
+-- +goose up
+ALTER TABLE feeds ADD COLUMN fetch_full_text BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE posts ADD COLUMN content TEXT;
+
+-- +goose Down
+ALTER TABLE posts DROP COLUMN content;
+ALTER TABLE feeds DROP COLUMN fetch_full_text;
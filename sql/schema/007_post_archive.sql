@@ -0,0 +1,7 @@
+-- +goose up
+ALTER TABLE posts ADD COLUMN archive_path TEXT;
+ALTER TABLE posts ADD COLUMN archived_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE posts DROP COLUMN archived_at;
+ALTER TABLE posts DROP COLUMN archive_path;
@@ -0,0 +1,15 @@
+-- +goose up
+ALTER TABLE feeds ADD COLUMN kind TEXT NOT NULL DEFAULT 'rss';
+ALTER TABLE feeds ADD COLUMN item_selector TEXT;
+ALTER TABLE feeds ADD COLUMN title_selector TEXT;
+ALTER TABLE feeds ADD COLUMN link_selector TEXT;
+ALTER TABLE feeds ADD COLUMN date_selector TEXT;
+ALTER TABLE feeds ADD COLUMN summary_selector TEXT;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN summary_selector;
+ALTER TABLE feeds DROP COLUMN date_selector;
+ALTER TABLE feeds DROP COLUMN link_selector;
+ALTER TABLE feeds DROP COLUMN title_selector;
+ALTER TABLE feeds DROP COLUMN item_selector;
+ALTER TABLE feeds DROP COLUMN kind;
@@ -0,0 +1,5 @@
+-- +goose up
+ALTER TABLE feeds ADD COLUMN credentials TEXT;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN credentials;
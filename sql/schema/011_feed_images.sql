@@ -0,0 +1,7 @@
+-- +goose up
+ALTER TABLE feeds ADD COLUMN image_url TEXT;
+ALTER TABLE feeds ADD COLUMN favicon_path TEXT;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN favicon_path;
+ALTER TABLE feeds DROP COLUMN image_url;
@@ -0,0 +1,11 @@
+-- +goose up
+ALTER TABLE feeds ADD COLUMN title TEXT;
+ALTER TABLE feeds ADD COLUMN description TEXT;
+ALTER TABLE feeds ADD COLUMN site_url TEXT;
+ALTER TABLE feeds ADD COLUMN language TEXT;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN language;
+ALTER TABLE feeds DROP COLUMN site_url;
+ALTER TABLE feeds DROP COLUMN description;
+ALTER TABLE feeds DROP COLUMN title;
@@ -0,0 +1,121 @@
+-- +goose up
+
+-- Moves every key from random integers to UUIDs. Each table gets its new id next to the old one, the
+-- references are looked up through the old ids, then the old columns are dropped and the new ones renamed.
+ALTER TABLE users ADD COLUMN new_id UUID NOT NULL DEFAULT gen_random_uuid();
+ALTER TABLE feeds ADD COLUMN new_id UUID NOT NULL DEFAULT gen_random_uuid();
+ALTER TABLE feed_follows ADD COLUMN new_id UUID NOT NULL DEFAULT gen_random_uuid();
+ALTER TABLE posts ADD COLUMN new_id UUID NOT NULL DEFAULT gen_random_uuid();
+
+ALTER TABLE feeds ADD COLUMN new_user_id UUID;
+UPDATE feeds SET new_user_id = users.new_id FROM users WHERE users.id = feeds.user_id;
+
+ALTER TABLE feed_follows ADD COLUMN new_user_id UUID;
+ALTER TABLE feed_follows ADD COLUMN new_feed_id UUID;
+UPDATE feed_follows SET new_user_id = users.new_id FROM users WHERE users.id = feed_follows.user_id;
+UPDATE feed_follows SET new_feed_id = feeds.new_id FROM feeds WHERE feeds.id = feed_follows.feed_id;
+
+ALTER TABLE posts ADD COLUMN new_feed_id UUID;
+UPDATE posts SET new_feed_id = feeds.new_id FROM feeds WHERE feeds.id = posts.feed_id;
+
+-- the callback urls hubs know contain the old feed ids, agg subscribes again with the new ones on the next poll
+DROP TABLE websub_subscriptions;
+
+ALTER TABLE feed_follows DROP COLUMN id, DROP COLUMN user_id, DROP COLUMN feed_id;
+ALTER TABLE posts DROP COLUMN id, DROP COLUMN feed_id;
+ALTER TABLE feeds DROP COLUMN id CASCADE, DROP COLUMN user_id;
+ALTER TABLE users DROP COLUMN id CASCADE;
+
+ALTER TABLE users RENAME COLUMN new_id TO id;
+ALTER TABLE users ADD PRIMARY KEY (id);
+
+ALTER TABLE feeds RENAME COLUMN new_id TO id;
+ALTER TABLE feeds RENAME COLUMN new_user_id TO user_id;
+ALTER TABLE feeds ADD PRIMARY KEY (id);
+ALTER TABLE feeds ADD FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE feed_follows RENAME COLUMN new_id TO id;
+ALTER TABLE feed_follows RENAME COLUMN new_user_id TO user_id;
+ALTER TABLE feed_follows RENAME COLUMN new_feed_id TO feed_id;
+ALTER TABLE feed_follows ADD PRIMARY KEY (id);
+ALTER TABLE feed_follows ADD FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE feed_follows ADD FOREIGN KEY (feed_id) REFERENCES feeds(id) ON DELETE CASCADE;
+ALTER TABLE feed_follows ADD UNIQUE (user_id, feed_id);
+
+ALTER TABLE posts RENAME COLUMN new_id TO id;
+ALTER TABLE posts RENAME COLUMN new_feed_id TO feed_id;
+ALTER TABLE posts ADD PRIMARY KEY (id);
+ALTER TABLE posts ADD FOREIGN KEY (feed_id) REFERENCES feeds(id) ON DELETE CASCADE;
+
+CREATE TABLE websub_subscriptions(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    feed_id UUID UNIQUE NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    hub_url TEXT NOT NULL,
+    topic_url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    lease_expires_at TIMESTAMP
+);
+
+-- +goose Down
+
+-- Numbers the rows again in creation order, the same steps backwards.
+ALTER TABLE users ADD COLUMN old_id INTEGER;
+ALTER TABLE feeds ADD COLUMN old_id INTEGER;
+ALTER TABLE feed_follows ADD COLUMN old_id INTEGER;
+ALTER TABLE posts ADD COLUMN old_id INTEGER;
+UPDATE users SET old_id = n.rn FROM (SELECT id, ROW_NUMBER() OVER (ORDER BY created_at, id) AS rn FROM users) n WHERE n.id = users.id;
+UPDATE feeds SET old_id = n.rn FROM (SELECT id, ROW_NUMBER() OVER (ORDER BY created_at, id) AS rn FROM feeds) n WHERE n.id = feeds.id;
+UPDATE feed_follows SET old_id = n.rn FROM (SELECT id, ROW_NUMBER() OVER (ORDER BY created_at, id) AS rn FROM feed_follows) n WHERE n.id = feed_follows.id;
+UPDATE posts SET old_id = n.rn FROM (SELECT id, ROW_NUMBER() OVER (ORDER BY created_at, id) AS rn FROM posts) n WHERE n.id = posts.id;
+
+ALTER TABLE feeds ADD COLUMN old_user_id INTEGER;
+UPDATE feeds SET old_user_id = users.old_id FROM users WHERE users.id = feeds.user_id;
+
+ALTER TABLE feed_follows ADD COLUMN old_user_id INTEGER;
+ALTER TABLE feed_follows ADD COLUMN old_feed_id INTEGER;
+UPDATE feed_follows SET old_user_id = users.old_id FROM users WHERE users.id = feed_follows.user_id;
+UPDATE feed_follows SET old_feed_id = feeds.old_id FROM feeds WHERE feeds.id = feed_follows.feed_id;
+
+ALTER TABLE posts ADD COLUMN old_feed_id INTEGER;
+UPDATE posts SET old_feed_id = feeds.old_id FROM feeds WHERE feeds.id = posts.feed_id;
+
+DROP TABLE websub_subscriptions;
+
+ALTER TABLE feed_follows DROP COLUMN id, DROP COLUMN user_id, DROP COLUMN feed_id;
+ALTER TABLE posts DROP COLUMN id, DROP COLUMN feed_id;
+ALTER TABLE feeds DROP COLUMN id CASCADE, DROP COLUMN user_id;
+ALTER TABLE users DROP COLUMN id CASCADE;
+
+ALTER TABLE users RENAME COLUMN old_id TO id;
+ALTER TABLE users ADD PRIMARY KEY (id);
+
+ALTER TABLE feeds RENAME COLUMN old_id TO id;
+ALTER TABLE feeds RENAME COLUMN old_user_id TO user_id;
+ALTER TABLE feeds ADD PRIMARY KEY (id);
+ALTER TABLE feeds ADD FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE feed_follows RENAME COLUMN old_id TO id;
+ALTER TABLE feed_follows RENAME COLUMN old_user_id TO user_id;
+ALTER TABLE feed_follows RENAME COLUMN old_feed_id TO feed_id;
+ALTER TABLE feed_follows ADD PRIMARY KEY (id);
+ALTER TABLE feed_follows ADD FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE feed_follows ADD FOREIGN KEY (feed_id) REFERENCES feeds(id) ON DELETE CASCADE;
+ALTER TABLE feed_follows ADD UNIQUE (user_id, feed_id);
+
+ALTER TABLE posts RENAME COLUMN old_id TO id;
+ALTER TABLE posts RENAME COLUMN old_feed_id TO feed_id;
+ALTER TABLE posts ADD PRIMARY KEY (id);
+ALTER TABLE posts ADD FOREIGN KEY (feed_id) REFERENCES feeds(id) ON DELETE CASCADE;
+
+CREATE TABLE websub_subscriptions(
+    id INTEGER PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    feed_id INTEGER UNIQUE NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    hub_url TEXT NOT NULL,
+    topic_url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    lease_expires_at TIMESTAMP
+);
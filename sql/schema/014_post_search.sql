@@ -0,0 +1,15 @@
+-- +goose up
+-- post_search is what gator search looks in, the title counts more than the text (weight A over B), markup is left out.
+-- It's an index on the expression rather than a column, so SELECT * on posts doesn't drag a tsvector along.
+-- +goose StatementBegin
+CREATE FUNCTION post_search(title TEXT, description TEXT, content TEXT) RETURNS tsvector
+LANGUAGE SQL IMMUTABLE AS $$
+    SELECT setweight(to_tsvector('english', title), 'A') ||
+        setweight(to_tsvector('english', regexp_replace(description || ' ' || coalesce(content, ''), '<[^>]*>', ' ', 'g')), 'B')
+$$;
+-- +goose StatementEnd
+CREATE INDEX posts_search_idx ON posts USING GIN (post_search(title, description, content));
+
+-- +goose Down
+DROP INDEX posts_search_idx;
+DROP FUNCTION post_search(TEXT, TEXT, TEXT);
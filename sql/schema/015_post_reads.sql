@@ -0,0 +1,12 @@
+-- +goose up
+-- a post is read by a user once it has a row here, everything else is unread
+CREATE TABLE post_reads(
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    read_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, post_id)
+);
+CREATE INDEX post_reads_post_id_idx ON post_reads(post_id);
+
+-- +goose Down
+DROP TABLE post_reads;
@@ -0,0 +1,26 @@
+-- +goose up
+-- a saved post keeps its own copy of the post, so it outlives the post, its feed and the follow
+CREATE TABLE bookmarks(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id UUID REFERENCES posts(id) ON DELETE SET NULL,
+    url TEXT NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL,
+    published_at TIMESTAMP,
+    feed_name TEXT,
+    note TEXT,
+    UNIQUE (user_id, url)
+);
+
+CREATE TABLE bookmark_tags(
+    bookmark_id UUID NOT NULL REFERENCES bookmarks(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (bookmark_id, tag)
+);
+
+-- +goose Down
+DROP TABLE bookmark_tags;
+DROP TABLE bookmarks;
@@ -0,0 +1,8 @@
+-- +goose up
+-- the folder a user files a followed feed under, NULL for feeds that aren't in one
+ALTER TABLE feed_follows ADD COLUMN folder TEXT;
+CREATE INDEX feed_follows_folder_idx ON feed_follows(user_id, folder);
+
+-- +goose Down
+DROP INDEX feed_follows_folder_idx;
+ALTER TABLE feed_follows DROP COLUMN folder;
@@ -0,0 +1,8 @@
+// Package schema holds the goose migrations, embedded so the gator binary can apply them itself.
+// sqlite/ has the versions of the ones SQLite needs written differently.
+package schema
+
+import "embed"
+
+//go:embed *.sql sqlite/*.sql
+var Migrations embed.FS
@@ -0,0 +1,212 @@
+-- +goose up
+
+-- SQLite can't change a column's type or primary key, so every table is built again with UUID keys and the
+-- rows copied over. The uuid_* tables map each old id to its new one while the references are rewritten.
+CREATE TABLE uuid_users AS SELECT id AS old_id, lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6))) AS new_id FROM users;
+CREATE TABLE uuid_feeds AS SELECT id AS old_id, lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6))) AS new_id FROM feeds;
+
+CREATE TABLE new_users(
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    name TEXT UNIQUE NOT NULL
+);
+INSERT INTO new_users
+SELECT m.new_id, u.created_at, u.updated_at, u.name
+FROM users u JOIN uuid_users m ON m.old_id = u.id;
+
+CREATE TABLE new_feeds(
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    name TEXT UNIQUE NOT NULL,
+    url TEXT UNIQUE NOT NULL,
+    user_id TEXT REFERENCES new_users(id) ON DELETE CASCADE,
+    last_fetched_at TIMESTAMP,
+    fetch_full_text BOOLEAN NOT NULL DEFAULT FALSE,
+    kind TEXT NOT NULL DEFAULT 'rss',
+    item_selector TEXT,
+    title_selector TEXT,
+    link_selector TEXT,
+    date_selector TEXT,
+    summary_selector TEXT,
+    credentials TEXT,
+    image_url TEXT,
+    favicon_path TEXT,
+    title TEXT,
+    description TEXT,
+    site_url TEXT,
+    language TEXT
+);
+INSERT INTO new_feeds
+SELECT m.new_id, f.created_at, f.updated_at, f.name, f.url, mu.new_id, f.last_fetched_at, f.fetch_full_text,
+       f.kind, f.item_selector, f.title_selector, f.link_selector, f.date_selector, f.summary_selector,
+       f.credentials, f.image_url, f.favicon_path, f.title, f.description, f.site_url, f.language
+FROM feeds f
+JOIN uuid_feeds m ON m.old_id = f.id
+LEFT JOIN uuid_users mu ON mu.old_id = f.user_id;
+
+CREATE TABLE new_feed_follows(
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id TEXT REFERENCES new_users(id) ON DELETE CASCADE,
+    feed_id TEXT REFERENCES new_feeds(id) ON DELETE CASCADE,
+    UNIQUE(user_id, feed_id)
+);
+INSERT INTO new_feed_follows
+SELECT lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6))),
+       a.created_at, a.updated_at, mu.new_id, mf.new_id
+FROM feed_follows a
+LEFT JOIN uuid_users mu ON mu.old_id = a.user_id
+LEFT JOIN uuid_feeds mf ON mf.old_id = a.feed_id;
+
+CREATE TABLE new_posts(
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    title TEXT UNIQUE NOT NULL,
+    url TEXT UNIQUE NOT NULL,
+    description TEXT NOT NULL,
+    published_at TIMESTAMP,
+    feed_id TEXT REFERENCES new_feeds(id) ON DELETE CASCADE,
+    content TEXT,
+    archive_path TEXT,
+    archived_at TIMESTAMP
+);
+INSERT INTO new_posts
+SELECT lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6))),
+       p.created_at, p.updated_at, p.title, p.url, p.description, p.published_at, mf.new_id,
+       p.content, p.archive_path, p.archived_at
+FROM posts p
+LEFT JOIN uuid_feeds mf ON mf.old_id = p.feed_id;
+
+-- children first, so dropping a table never cascades into one we still copy from
+DROP TABLE websub_subscriptions;
+DROP TABLE posts;
+DROP TABLE feed_follows;
+DROP TABLE feeds;
+DROP TABLE users;
+DROP TABLE uuid_feeds;
+DROP TABLE uuid_users;
+
+-- renaming also rewrites the references to new_users and new_feeds in the other tables
+ALTER TABLE new_users RENAME TO users;
+ALTER TABLE new_feeds RENAME TO feeds;
+ALTER TABLE new_feed_follows RENAME TO feed_follows;
+ALTER TABLE new_posts RENAME TO posts;
+
+-- the callback urls hubs know contain the old feed ids, agg subscribes again with the new ones on the next poll
+CREATE TABLE websub_subscriptions(
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    feed_id TEXT UNIQUE NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    hub_url TEXT NOT NULL,
+    topic_url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    lease_expires_at TIMESTAMP
+);
+
+-- +goose Down
+
+CREATE TABLE int_users AS SELECT id AS old_id, ROW_NUMBER() OVER (ORDER BY created_at, id) AS new_id FROM users;
+CREATE TABLE int_feeds AS SELECT id AS old_id, ROW_NUMBER() OVER (ORDER BY created_at, id) AS new_id FROM feeds;
+
+CREATE TABLE new_users(
+    id INTEGER PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    name TEXT UNIQUE NOT NULL
+);
+INSERT INTO new_users
+SELECT m.new_id, u.created_at, u.updated_at, u.name
+FROM users u JOIN int_users m ON m.old_id = u.id;
+
+CREATE TABLE new_feeds(
+    id INTEGER PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    name TEXT UNIQUE NOT NULL,
+    url TEXT UNIQUE NOT NULL,
+    user_id INTEGER REFERENCES new_users(id) ON DELETE CASCADE,
+    last_fetched_at TIMESTAMP,
+    fetch_full_text BOOLEAN NOT NULL DEFAULT FALSE,
+    kind TEXT NOT NULL DEFAULT 'rss',
+    item_selector TEXT,
+    title_selector TEXT,
+    link_selector TEXT,
+    date_selector TEXT,
+    summary_selector TEXT,
+    credentials TEXT,
+    image_url TEXT,
+    favicon_path TEXT,
+    title TEXT,
+    description TEXT,
+    site_url TEXT,
+    language TEXT
+);
+INSERT INTO new_feeds
+SELECT m.new_id, f.created_at, f.updated_at, f.name, f.url, mu.new_id, f.last_fetched_at, f.fetch_full_text,
+       f.kind, f.item_selector, f.title_selector, f.link_selector, f.date_selector, f.summary_selector,
+       f.credentials, f.image_url, f.favicon_path, f.title, f.description, f.site_url, f.language
+FROM feeds f
+JOIN int_feeds m ON m.old_id = f.id
+LEFT JOIN int_users mu ON mu.old_id = f.user_id;
+
+CREATE TABLE new_feed_follows(
+    id INTEGER PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id INTEGER REFERENCES new_users(id) ON DELETE CASCADE,
+    feed_id INTEGER REFERENCES new_feeds(id) ON DELETE CASCADE,
+    UNIQUE(user_id, feed_id)
+);
+INSERT INTO new_feed_follows
+SELECT ROW_NUMBER() OVER (ORDER BY a.created_at, a.id), a.created_at, a.updated_at, mu.new_id, mf.new_id
+FROM feed_follows a
+LEFT JOIN int_users mu ON mu.old_id = a.user_id
+LEFT JOIN int_feeds mf ON mf.old_id = a.feed_id;
+
+CREATE TABLE new_posts(
+    id INTEGER UNIQUE PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    title TEXT UNIQUE NOT NULL,
+    url TEXT UNIQUE NOT NULL,
+    description TEXT NOT NULL,
+    published_at TIMESTAMP,
+    feed_id INTEGER REFERENCES new_feeds(id) ON DELETE CASCADE,
+    content TEXT,
+    archive_path TEXT,
+    archived_at TIMESTAMP
+);
+INSERT INTO new_posts
+SELECT ROW_NUMBER() OVER (ORDER BY p.created_at, p.id), p.created_at, p.updated_at, p.title, p.url, p.description,
+       p.published_at, mf.new_id, p.content, p.archive_path, p.archived_at
+FROM posts p
+LEFT JOIN int_feeds mf ON mf.old_id = p.feed_id;
+
+DROP TABLE websub_subscriptions;
+DROP TABLE posts;
+DROP TABLE feed_follows;
+DROP TABLE feeds;
+DROP TABLE users;
+DROP TABLE int_feeds;
+DROP TABLE int_users;
+
+ALTER TABLE new_users RENAME TO users;
+ALTER TABLE new_feeds RENAME TO feeds;
+ALTER TABLE new_feed_follows RENAME TO feed_follows;
+ALTER TABLE new_posts RENAME TO posts;
+
+CREATE TABLE websub_subscriptions(
+    id INTEGER PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    feed_id INTEGER UNIQUE NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    hub_url TEXT NOT NULL,
+    topic_url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    lease_expires_at TIMESTAMP
+);
@@ -0,0 +1,27 @@
+-- +goose up
+
+-- SQLite has no tsvector, its full-text search is an FTS5 table kept in step with posts by the triggers below.
+
+CREATE VIRTUAL TABLE posts_fts USING fts5(post_id UNINDEXED, title, body, tokenize = 'porter unicode61');
+
+INSERT INTO posts_fts(post_id, title, body)
+SELECT id, title, description || ' ' || coalesce(content, '') FROM posts;
+
+CREATE TRIGGER posts_fts_insert AFTER INSERT ON posts BEGIN
+    INSERT INTO posts_fts(post_id, title, body) VALUES (new.id, new.title, new.description || ' ' || coalesce(new.content, ''));
+END;
+
+CREATE TRIGGER posts_fts_update AFTER UPDATE OF title, description, content ON posts BEGIN
+    DELETE FROM posts_fts WHERE post_id = old.id;
+    INSERT INTO posts_fts(post_id, title, body) VALUES (new.id, new.title, new.description || ' ' || coalesce(new.content, ''));
+END;
+
+CREATE TRIGGER posts_fts_delete AFTER DELETE ON posts BEGIN
+    DELETE FROM posts_fts WHERE post_id = old.id;
+END;
+
+-- +goose Down
+DROP TRIGGER posts_fts_delete;
+DROP TRIGGER posts_fts_update;
+DROP TRIGGER posts_fts_insert;
+DROP TABLE posts_fts;
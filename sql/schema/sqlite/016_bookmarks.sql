@@ -0,0 +1,26 @@
+-- +goose up
+-- the same tables with the TEXT ids SQLite uses for UUIDs (see 013_uuid_keys.sql)
+CREATE TABLE bookmarks(
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id TEXT REFERENCES posts(id) ON DELETE SET NULL,
+    url TEXT NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL,
+    published_at TIMESTAMP,
+    feed_name TEXT,
+    note TEXT,
+    UNIQUE (user_id, url)
+);
+
+CREATE TABLE bookmark_tags(
+    bookmark_id TEXT NOT NULL REFERENCES bookmarks(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (bookmark_id, tag)
+);
+
+-- +goose Down
+DROP TABLE bookmark_tags;
+DROP TABLE bookmarks;